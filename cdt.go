@@ -0,0 +1,254 @@
+package tds
+
+import "fmt"
+
+// This file adds constrained Delaunay triangulation support: InsertSegment
+// forces an edge into the triangulation, and InsertPolygon/MarkHoles build
+// on it for closed boundaries and interior exclusion zones. Constrained
+// edges are tracked in st.c (both directions, so a lookup doesn't need to
+// know which order a caller used) and dig consults it to refuse flipping
+// them back out during later vertex insertions.
+
+// hasEdge reports whether the directed edge u->v already appears in the
+// triangulation.
+func (st *Store2D) hasEdge(u, v Vec2) bool {
+	_, ok := st.m[Mat2{u, v}]
+	return ok
+}
+
+func (st *Store2D) markConstrained(u, v Vec2) {
+	st.c[Mat2{u, v}] = true
+	st.c[Mat2{v, u}] = true
+}
+
+// constrained reports whether the edge between u and v must not be flipped.
+func (st *Store2D) constrained(u, v Vec2) bool {
+	return st.c[Mat2{u, v}]
+}
+
+func rotateToFront(t Triangle, v Vec2) (Triangle, bool) {
+	for i, p := range t {
+		if p == v {
+			return Triangle{t[i], t[(i+1)%3], t[(i+2)%3]}, true
+		}
+	}
+	return t, false
+}
+
+// vertexWedgeContains reports whether b lies in the angular wedge a
+// triangle (a, p1, p2), in that CCW order, sweeps out at vertex a.
+func vertexWedgeContains(a, p1, p2, b Vec2) bool {
+	return Orient2DRobust(a, p1, b) >= 0 && Orient2DRobust(a, p2, b) <= 0
+}
+
+// findWedge returns the other two vertices of a real (non-ghost) triangle
+// incident to a whose wedge at a faces toward b.
+func (st *Store2D) findWedge(a, b Vec2) (p1, p2 Vec2, ok bool) {
+	for t := range st.tri {
+		if t[0] == GhostVertex || t[1] == GhostVertex || t[2] == GhostVertex {
+			continue
+		}
+		rt, found := rotateToFront(t, a)
+		if !found {
+			continue
+		}
+		if vertexWedgeContains(a, rt[1], rt[2], b) {
+			return rt[1], rt[2], true
+		}
+	}
+	return Vec2{}, Vec2{}, false
+}
+
+// crossedStrip walks the triangles the open segment a-b passes through,
+// returning them (for deletion) along with the chain of vertices bounding
+// the cavity on each side of the segment, in order from a to b.
+func (st *Store2D) crossedStrip(a, b Vec2) (crossed []Triangle, upper, lower []Vec2, err error) {
+	p1, p2, ok := st.findWedge(a, b)
+	if !ok {
+		return nil, nil, nil, fmt.Errorf("no triangle incident to %v faces %v", a, b)
+	}
+
+	crossed = append(crossed, Triangle{a, p1, p2})
+	pl, pu := p1, p2
+	upper = append(upper, pu)
+	lower = append(lower, pl)
+
+	for {
+		x, ok := st.Adjacent(pu, pl)
+		if !ok {
+			return nil, nil, nil, fmt.Errorf("triangulation has no triangle across %v-%v while routing to %v", pl, pu, b)
+		}
+		if x == GhostVertex {
+			return nil, nil, nil, fmt.Errorf("segment %v-%v exits the triangulated region near %v-%v", a, b, pl, pu)
+		}
+		crossed = append(crossed, Triangle{pu, pl, x})
+		if x == b {
+			return crossed, upper, lower, nil
+		}
+		switch side := Orient2DRobust(a, b, x); {
+		case side > 0:
+			upper = append(upper, x)
+			pu = x
+		case side < 0:
+			lower = append(lower, x)
+			pl = x
+		default:
+			return nil, nil, nil, fmt.Errorf("vertex %v lies on segment %v-%v", x, a, b)
+		}
+	}
+}
+
+func reverseVec2(a []Vec2) []Vec2 {
+	out := make([]Vec2, len(a))
+	for i, v := range a {
+		out[len(a)-1-i] = v
+	}
+	return out
+}
+
+// InsertSegment forces the edge a-b to appear in the triangulation. If the
+// edge isn't already present, it collects the strip of triangles a-b
+// crosses, deletes them to form two cavity polygons (one on each side of
+// the segment), and re-triangulates each with ear-clipping. The new edge
+// a-b is recorded as constrained so later vertex insertions (via dig) won't
+// flip it away.
+func (st *Store2D) InsertSegment(a, b Vec2) error {
+	if a == b {
+		return fmt.Errorf("segment endpoints must differ, have %v", a)
+	}
+	if st.hasEdge(a, b) || st.hasEdge(b, a) {
+		st.markConstrained(a, b)
+		return nil
+	}
+
+	crossed, upper, lower, err := st.crossedStrip(a, b)
+	if err != nil {
+		return err
+	}
+	for _, t := range crossed {
+		if err := st.DeleteTriangle(t[0], t[1], t[2]); err != nil {
+			return err
+		}
+	}
+
+	upperPoly := append([]Vec2{a, b}, reverseVec2(upper)...)
+	lowerPoly := append(append([]Vec2{a}, lower...), b)
+	for _, t := range earClip(upperPoly) {
+		if err := st.AddTriangle(t[0], t[1], t[2]); err != nil {
+			return err
+		}
+	}
+	for _, t := range earClip(lowerPoly) {
+		if err := st.AddTriangle(t[0], t[1], t[2]); err != nil {
+			return err
+		}
+	}
+
+	st.markConstrained(a, b)
+	return nil
+}
+
+// InsertPolygon forces every edge of the closed boundary poly (vertices in
+// order, implicitly closing from the last back to the first) into the
+// triangulation.
+func (st *Store2D) InsertPolygon(poly []Vec2) error {
+	for i := range poly {
+		a, b := poly[i], poly[(i+1)%len(poly)]
+		if err := st.InsertSegment(a, b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MarkHoles constrains poly's boundary into the triangulation and then
+// deletes every triangle whose centroid falls inside it, excluding that
+// region from the mesh.
+func (st *Store2D) MarkHoles(poly []Vec2) error {
+	if err := st.InsertPolygon(poly); err != nil {
+		return err
+	}
+	var holes []Triangle
+	for t := range st.tri {
+		if pointInPolygon(t.centroid(), poly) {
+			holes = append(holes, t)
+		}
+	}
+	for _, t := range holes {
+		if err := st.DeleteTriangle(t[0], t[1], t[2]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func pointInPolygon(p Vec2, poly []Vec2) bool {
+	inside := false
+	for i, j := 0, len(poly)-1; i < len(poly); j, i = i, i+1 {
+		pi, pj := poly[i], poly[j]
+		if (pi[1] > p[1]) != (pj[1] > p[1]) &&
+			p[0] < (pj[0]-pi[0])*(p[1]-pi[1])/(pj[1]-pi[1])+pi[0] {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+func pointInTriangle(p, a, b, c Vec2) bool {
+	d1 := Orient2DRobust(a, b, p)
+	d2 := Orient2DRobust(b, c, p)
+	d3 := Orient2DRobust(c, a, p)
+	neg := d1 < 0 || d2 < 0 || d3 < 0
+	pos := d1 > 0 || d2 > 0 || d3 > 0
+	return !(neg && pos)
+}
+
+// earClip triangulates the simple, counter-clockwise-wound polygon poly by
+// repeatedly clipping convex vertices ("ears") that contain no other
+// polygon vertex, the textbook O(n^2) ear-clipping method.
+func earClip(poly []Vec2) []Triangle {
+	n := len(poly)
+	if n < 3 {
+		return nil
+	}
+	idx := make([]int, n)
+	for i := range idx {
+		idx[i] = i
+	}
+
+	var tris []Triangle
+	for len(idx) > 2 {
+		clipped := false
+		for i := range idx {
+			i0 := idx[(i-1+len(idx))%len(idx)]
+			i1 := idx[i]
+			i2 := idx[(i+1)%len(idx)]
+			a, b, c := poly[i0], poly[i1], poly[i2]
+			if Orient2DRobust(a, b, c) <= 0 {
+				continue // reflex or degenerate vertex: not an ear
+			}
+			isEar := true
+			for _, j := range idx {
+				if j == i0 || j == i1 || j == i2 {
+					continue
+				}
+				if pointInTriangle(poly[j], a, b, c) {
+					isEar = false
+					break
+				}
+			}
+			if !isEar {
+				continue
+			}
+			tris = append(tris, Triangle{a, b, c})
+			idx = append(append([]int{}, idx[:i]...), idx[i+1:]...)
+			clipped = true
+			break
+		}
+		if !clipped {
+			// poly wasn't simple/CCW as expected; stop rather than spin.
+			break
+		}
+	}
+	return tris
+}
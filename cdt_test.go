@@ -0,0 +1,132 @@
+package tds
+
+import "testing"
+
+func gridStore2D(t *testing.T) (*Store2D, [][3]Vec2) {
+	t.Helper()
+	st := NewStore2D()
+	v := func(x, y float32) Vec2 { return Vec2{x, y} }
+	p00, p10, p20 := v(0, 0), v(1, 0), v(2, 0)
+	p01, p11, p21 := v(0, 1), v(1, 1), v(2, 1)
+	p02, p12, p22 := v(0, 2), v(1, 2), v(2, 2)
+
+	tris := [][3]Vec2{
+		{p00, p10, p11}, {p00, p11, p01},
+		{p10, p20, p21}, {p10, p21, p11},
+		{p01, p11, p12}, {p01, p12, p02},
+		{p11, p21, p22}, {p11, p22, p12},
+	}
+	for _, tr := range tris {
+		if err := st.AddTriangle(tr[0], tr[1], tr[2]); err != nil {
+			t.Fatalf("AddTriangle(%v): %v", tr, err)
+		}
+	}
+	return st, tris
+}
+
+func TestStore2DInsertSegment(t *testing.T) {
+	st, tris := gridStore2D(t)
+	a, b := Vec2{0, 0}, Vec2{2, 1}
+
+	if err := st.InsertSegment(a, b); err != nil {
+		t.Fatalf("InsertSegment: %v", err)
+	}
+	if !st.constrained(a, b) {
+		t.Errorf("want %v-%v constrained", a, b)
+	}
+	if !st.hasEdge(a, b) && !st.hasEdge(b, a) {
+		t.Errorf("want %v-%v to be an edge in the mesh", a, b)
+	}
+	if len(st.tri) != len(tris) {
+		t.Errorf("want %d triangles after re-triangulating the cavity, have %d", len(tris), len(st.tri))
+	}
+
+	// Every interior edge must still have a twin on the adjacent triangle;
+	// only the 8 hull edges of the 2x2 grid should be unmatched.
+	unmatched := 0
+	for e0 := range st.m {
+		if _, ok := st.m[Mat2{e0[1], e0[0]}]; !ok {
+			unmatched++
+		}
+	}
+	if unmatched != 8 {
+		t.Errorf("want 8 unmatched hull edges, have %v", unmatched)
+	}
+
+	// Inserting the same segment again should be a no-op, not an error.
+	if err := st.InsertSegment(a, b); err != nil {
+		t.Errorf("re-inserting an existing segment: %v", err)
+	}
+}
+
+// TestStore2DInsertSegmentConcave exercises InsertSegment on a non-convex
+// (L-shaped) triangulation, the PCB-routing-outline scenario the feature is
+// meant for: a chord that stays within the material should succeed. The
+// other half of the scenario, a chord across the removed notch being
+// rejected, is checked by TestStore2DInsertSegmentRejectsNotchCrossing.
+func TestStore2DInsertSegmentConcave(t *testing.T) {
+	st := lStore2D(t)
+
+	a, b := Vec2{0, 0}, Vec2{10, 5}
+	if err := st.InsertSegment(a, b); err != nil {
+		t.Fatalf("InsertSegment within the material: %v", err)
+	}
+	if !st.constrained(a, b) {
+		t.Errorf("want %v-%v constrained", a, b)
+	}
+}
+
+func TestStore2DInsertSegmentRejectsNotchCrossing(t *testing.T) {
+	st := lStore2D(t)
+	before := len(st.tri)
+
+	a, b := Vec2{10, 0}, Vec2{5, 10}
+	if err := st.InsertSegment(a, b); err == nil {
+		t.Fatalf("want an error crossing the removed notch, got nil")
+	}
+	if st.constrained(a, b) {
+		t.Errorf("want %v-%v not constrained after a rejected insertion", a, b)
+	}
+	if after := len(st.tri); after != before {
+		t.Errorf("want the triangle count unchanged after a rejected insertion (no notch-filling triangle fabricated), had %d now %d", before, after)
+	}
+}
+
+func TestStore2DMarkHoles(t *testing.T) {
+	st := NewStore2D()
+	v := func(x, y float32) Vec2 { return Vec2{x, y} }
+	p00, p10, p20, p30 := v(0, 0), v(1, 0), v(2, 0), v(3, 0)
+	p01, p11, p21, p31 := v(0, 1), v(1, 1), v(2, 1), v(3, 1)
+	p02, p12, p22, p32 := v(0, 2), v(1, 2), v(2, 2), v(3, 2)
+	p03, p13, p23, p33 := v(0, 3), v(1, 3), v(2, 3), v(3, 3)
+
+	cells := [][4]Vec2{
+		{p00, p10, p01, p11}, {p10, p20, p11, p21}, {p20, p30, p21, p31},
+		{p01, p11, p02, p12}, {p11, p21, p12, p22}, {p21, p31, p22, p32},
+		{p02, p12, p03, p13}, {p12, p22, p13, p23}, {p22, p32, p23, p33},
+	}
+	for _, c := range cells {
+		a, b, d, e := c[0], c[1], c[2], c[3]
+		if err := st.AddTriangle(a, b, e); err != nil {
+			t.Fatalf("AddTriangle: %v", err)
+		}
+		if err := st.AddTriangle(a, e, d); err != nil {
+			t.Fatalf("AddTriangle: %v", err)
+		}
+	}
+
+	before := len(st.tri)
+	hole := []Vec2{p11, p21, p22, p12}
+	if err := st.MarkHoles(hole); err != nil {
+		t.Fatalf("MarkHoles: %v", err)
+	}
+	if after := len(st.tri); after >= before {
+		t.Errorf("want fewer triangles after marking the hole, had %v now %v", before, after)
+	}
+	for i := range hole {
+		a, b := hole[i], hole[(i+1)%len(hole)]
+		if !st.constrained(a, b) {
+			t.Errorf("want hole boundary %v-%v constrained", a, b)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package tds
+
+import "sort"
+
+// This file extracts non-convex boundaries from a completed triangulation.
+// AlphaShape keeps the triangles small enough to be "covered" by a disk of
+// radius 1/alpha and returns the boundary of that union as a set of rings,
+// outer ring(s) first (signed area > 0) followed by holes (signed area <
+// 0) — the same winding convention GeoJSON/WKB polygons with holes use.
+// ConcaveHull derives an alpha from the triangulation's own point spacing
+// (its vertices' k-th nearest neighbour distance) and defers to AlphaShape,
+// reusing it rather than a separate k-nearest-neighbour walk.
+
+// circumradius returns the radius of the circle through a, b, and c.
+func circumradius(a, b, c Vec2) float32 {
+	cc := circumcenter(a, b, c)
+	var d Vec2
+	d.Sub(&a, &cc)
+	return sqrt(d.Dot(&d))
+}
+
+// AlphaShape returns the boundary rings of the union of triangles whose
+// circumradius is smaller than 1/alpha, the triangles a disk of that radius
+// can cover. A triangle's edge is emitted, with the kept region to its
+// left, whenever the triangle across that edge isn't also kept — including
+// hull edges, which have no triangle on the other side at all.
+func (st *Store2D) AlphaShape(alpha float32) [][]Vec2 {
+	r := 1 / alpha
+	kept := func(a, b, c Vec2) bool {
+		if a == GhostVertex || b == GhostVertex || c == GhostVertex {
+			return false
+		}
+		return circumradius(a, b, c) < r
+	}
+
+	var edges []Mat2
+	for _, t := range st.Triangles() {
+		u, v, w := t[0], t[1], t[2]
+		if !kept(u, v, w) {
+			continue
+		}
+		for _, e := range [3][2]Vec2{{u, v}, {v, w}, {w, u}} {
+			a, b := e[0], e[1]
+			x, ok := st.Adjacent(b, a)
+			if !ok || !kept(b, a, x) {
+				edges = append(edges, Mat2{a, b})
+			}
+		}
+	}
+	return stitchRings(edges)
+}
+
+// stitchRings walks directed edges (interior to their left) into closed
+// rings, then orders them outer ring(s) first by descending signed area.
+func stitchRings(edges []Mat2) [][]Vec2 {
+	next := make(map[Vec2]Vec2, len(edges))
+	for _, e := range edges {
+		next[e[0]] = e[1]
+	}
+
+	visited := make(map[Vec2]bool, len(edges))
+	var rings [][]Vec2
+	for _, e := range edges {
+		if visited[e[0]] {
+			continue
+		}
+		var ring []Vec2
+		for u := e[0]; !visited[u]; {
+			visited[u] = true
+			ring = append(ring, u)
+			v, ok := next[u]
+			if !ok {
+				break
+			}
+			u = v
+		}
+		if len(ring) >= 3 {
+			rings = append(rings, ring)
+		}
+	}
+
+	sort.SliceStable(rings, func(i, j int) bool { return ringArea(rings[i]) > ringArea(rings[j]) })
+	return rings
+}
+
+// ringArea returns ring's signed area: positive for counter-clockwise
+// winding, negative for clockwise.
+func ringArea(ring []Vec2) float32 {
+	var area float32
+	for i, a := range ring {
+		b := ring[(i+1)%len(ring)]
+		area += a[0]*b[1] - b[0]*a[1]
+	}
+	return area / 2
+}
+
+// kthNearestNeighborDistance returns the median, over every vertex, of that
+// vertex's distance to its k-th nearest neighbour — a characteristic point
+// spacing for the triangulation. It returns 0 if there are too few vertices
+// for k to be meaningful.
+func (st *Store2D) kthNearestNeighborDistance(k int) float32 {
+	verts := st.VertexSet()
+	if k <= 0 || k >= len(verts) {
+		return 0
+	}
+
+	kth := make([]float32, len(verts))
+	for i, u := range verts {
+		dists := make([]float32, 0, len(verts)-1)
+		for _, v := range verts {
+			if v == u {
+				continue
+			}
+			var d Vec2
+			d.Sub(&u, &v)
+			dists = append(dists, sqrt(d.Dot(&d)))
+		}
+		sort.Slice(dists, func(i, j int) bool { return dists[i] < dists[j] })
+		kth[i] = dists[k-1]
+	}
+
+	sort.Slice(kth, func(i, j int) bool { return kth[i] < kth[j] })
+	return kth[len(kth)/2]
+}
+
+// ConcaveHull approximates a concave hull using the triangulation's k-th
+// nearest neighbour point spacing to derive an alpha radius, then returns
+// AlphaShape(1/spacing). This is an approximation for very non-uniform
+// point densities, but reuses the same boundary-extraction machinery rather
+// than a separate k-nearest-neighbour walk.
+func (st *Store2D) ConcaveHull(k int) [][]Vec2 {
+	d := st.kthNearestNeighborDistance(k)
+	if d <= 0 {
+		// too few vertices for k: alpha 0 keeps every triangle, falling back to
+		// the triangulation's outer boundary (its convex hull, for a complete
+		// Delaunay triangulation).
+		return st.AlphaShape(0)
+	}
+	return st.AlphaShape(1 / d)
+}
@@ -0,0 +1,85 @@
+package tds
+
+import "testing"
+
+func squareStore2D(t *testing.T) *Store2D {
+	t.Helper()
+	st := NewStore2D()
+	v0, v1, v2, v3 := Vec2{0, 0}, Vec2{10, 0}, Vec2{10, 10}, Vec2{0, 10}
+	if err := st.AddTriangle(v0, v1, v2); err != nil {
+		t.Fatalf("AddTriangle: %v", err)
+	}
+	if err := st.AddTriangle(v0, v2, v3); err != nil {
+		t.Fatalf("AddTriangle: %v", err)
+	}
+	st.InsertGhost()
+	return st
+}
+
+// lStore2D returns an L-shaped (non-convex) triangulation: a 10x10 square
+// with its top-right quadrant removed, fanned from (0, 0).
+func lStore2D(t *testing.T) *Store2D {
+	t.Helper()
+	st := NewStore2D()
+	a, b, c, d, e, f := Vec2{0, 0}, Vec2{10, 0}, Vec2{10, 5}, Vec2{5, 5}, Vec2{5, 10}, Vec2{0, 10}
+	tris := [][3]Vec2{{a, b, c}, {a, c, d}, {a, d, e}, {a, e, f}}
+	for _, tr := range tris {
+		if err := st.AddTriangle(tr[0], tr[1], tr[2]); err != nil {
+			t.Fatalf("AddTriangle(%v): %v", tr, err)
+		}
+	}
+	st.InsertGhost()
+	return st
+}
+
+func TestStore2DAlphaShapeSquare(t *testing.T) {
+	st := squareStore2D(t)
+
+	rings := st.AlphaShape(0.1)
+	if len(rings) != 1 {
+		t.Fatalf("want 1 ring, have %d: %v", len(rings), rings)
+	}
+	if len(rings[0]) != 4 {
+		t.Fatalf("want a 4-vertex ring, have %v", rings[0])
+	}
+	if ringArea(rings[0]) <= 0 {
+		t.Errorf("want the outer ring wound counter-clockwise, have area %v", ringArea(rings[0]))
+	}
+}
+
+func TestStore2DAlphaShapeConcave(t *testing.T) {
+	st := lStore2D(t)
+
+	// alpha 0.1 (radius 10) keeps all four fan triangles (the largest
+	// circumradius is about 7.9), so the ring follows the L's own boundary,
+	// reflex corner included, rather than cutting across the notch.
+	rings := st.AlphaShape(0.1)
+	if len(rings) != 1 {
+		t.Fatalf("want 1 ring, have %d: %v", len(rings), rings)
+	}
+	if len(rings[0]) != 6 {
+		t.Fatalf("want the L-shape's 6 vertices, have %v", rings[0])
+	}
+}
+
+func TestStore2DAlphaShapeTooSmallIsEmpty(t *testing.T) {
+	st := squareStore2D(t)
+
+	// an alpha whose 1/alpha radius is smaller than every triangle's
+	// circumradius keeps nothing.
+	if rings := st.AlphaShape(10); len(rings) != 0 {
+		t.Errorf("want no rings, have %v", rings)
+	}
+}
+
+func TestStore2DConcaveHull(t *testing.T) {
+	st := lStore2D(t)
+
+	rings := st.ConcaveHull(3)
+	if len(rings) == 0 {
+		t.Fatal("want at least 1 ring")
+	}
+	if len(rings[0]) < 3 {
+		t.Errorf("want a valid ring, have %v", rings[0])
+	}
+}
@@ -0,0 +1,155 @@
+// Package geojson reads and writes tds triangulations as a GeoJSON
+// FeatureCollection of Polygon features, one per triangle, each ring closed
+// back to its first vertex. Store25D's real per-vertex elevation is kept as
+// a 3-element position ([x, y, z]) via the Z-suffixed functions; Store2D,
+// which has none, is written with 2-element ([x, y]) positions.
+package geojson
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/dskinner/tds"
+)
+
+type geometry struct {
+	Type        string        `json:"type"`
+	Coordinates [][][]float64 `json:"coordinates"`
+}
+
+type feature struct {
+	Type       string                 `json:"type"`
+	Geometry   geometry               `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+type featureCollection struct {
+	Type     string    `json:"type"`
+	Features []feature `json:"features"`
+}
+
+func triangleRing(a, b, c []float64) [][][]float64 {
+	return [][][]float64{{a, b, c, a}}
+}
+
+func nonGhostTriangles(st *tds.Store2D) []tds.Triangle {
+	var out []tds.Triangle
+	for _, t := range st.Triangles() {
+		if t[0] == tds.GhostVertex || t[1] == tds.GhostVertex || t[2] == tds.GhostVertex {
+			continue
+		}
+		out = append(out, t)
+	}
+	return out
+}
+
+// Encode writes st's triangles as a GeoJSON FeatureCollection of Polygon
+// features with 2D positions.
+func Encode(w io.Writer, st *tds.Store2D) error {
+	var fc featureCollection
+	fc.Type = "FeatureCollection"
+	for _, t := range nonGhostTriangles(st) {
+		pos := func(v tds.Vec2) []float64 { return []float64{float64(v[0]), float64(v[1])} }
+		fc.Features = append(fc.Features, feature{
+			Type:       "Feature",
+			Geometry:   geometry{Type: "Polygon", Coordinates: triangleRing(pos(t[0]), pos(t[1]), pos(t[2]))},
+			Properties: map[string]interface{}{},
+		})
+	}
+	return json.NewEncoder(w).Encode(fc)
+}
+
+// EncodeZ writes st's triangles as a GeoJSON FeatureCollection of Polygon
+// features with 3D positions carrying each vertex's elevation.
+func EncodeZ(w io.Writer, st *tds.Store25D) error {
+	var fc featureCollection
+	fc.Type = "FeatureCollection"
+	for _, t := range nonGhostTriangles(st.Store2D) {
+		tz := st.TriangleZ(t)
+		pos := func(v tds.Vec3) []float64 { return []float64{float64(v[0]), float64(v[1]), float64(v[2])} }
+		fc.Features = append(fc.Features, feature{
+			Type:       "Feature",
+			Geometry:   geometry{Type: "Polygon", Coordinates: triangleRing(pos(tz[0]), pos(tz[1]), pos(tz[2]))},
+			Properties: map[string]interface{}{},
+		})
+	}
+	return json.NewEncoder(w).Encode(fc)
+}
+
+func decodeFeatures(r io.Reader) ([]feature, error) {
+	var fc featureCollection
+	if err := json.NewDecoder(r).Decode(&fc); err != nil {
+		return nil, err
+	}
+	for _, f := range fc.Features {
+		if f.Geometry.Type != "Polygon" {
+			return nil, fmt.Errorf("geojson: Decode expects Polygon features, have %q", f.Geometry.Type)
+		}
+		if len(f.Geometry.Coordinates) != 1 || len(f.Geometry.Coordinates[0]) != 4 {
+			return nil, fmt.Errorf("geojson: Decode expects a single 4-point (closed) triangle ring")
+		}
+	}
+	return fc.Features, nil
+}
+
+// Decode reads a GeoJSON FeatureCollection of Polygon features (as Encode
+// writes) back into a Store2D, re-orienting each triangle counter-clockwise
+// as Store2D requires. Z, if present, is discarded; use DecodeZ to keep it.
+func Decode(r io.Reader) (*tds.Store2D, error) {
+	features, err := decodeFeatures(r)
+	if err != nil {
+		return nil, err
+	}
+	st := tds.NewStore2D()
+	for _, f := range features {
+		ring := f.Geometry.Coordinates[0]
+		u := tds.Vec2{float32(ring[0][0]), float32(ring[0][1])}
+		v := tds.Vec2{float32(ring[1][0]), float32(ring[1][1])}
+		w := tds.Vec2{float32(ring[2][0]), float32(ring[2][1])}
+		if tds.Orient2D(u, v, w) < 0 {
+			v, w = w, v
+		}
+		if err := st.AddTriangle(u, v, w); err != nil {
+			return nil, err
+		}
+	}
+	st.InsertGhost()
+	return st, nil
+}
+
+// DecodeZ is Decode, but keeps each vertex's elevation (its position's 3rd
+// element) in the returned Store25D.
+func DecodeZ(r io.Reader) (*tds.Store25D, error) {
+	features, err := decodeFeatures(r)
+	if err != nil {
+		return nil, err
+	}
+	st := tds.NewStore25D()
+	for _, f := range features {
+		ring := f.Geometry.Coordinates[0]
+		pt := func(i int) (tds.Vec2, float32) {
+			p := ring[i]
+			var z float64
+			if len(p) > 2 {
+				z = p[2]
+			}
+			return tds.Vec2{float32(p[0]), float32(p[1])}, float32(z)
+		}
+		u, zu := pt(0)
+		v, zv := pt(1)
+		w, zw := pt(2)
+		if tds.Orient2D(u, v, w) < 0 {
+			v, w = w, v
+			zv, zw = zw, zv
+		}
+		st.SetZ(u, zu)
+		st.SetZ(v, zv)
+		st.SetZ(w, zw)
+		if err := st.AddTriangle(u, v, w); err != nil {
+			return nil, err
+		}
+	}
+	st.InsertGhost()
+	return st, nil
+}
@@ -0,0 +1,60 @@
+package geojson
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/dskinner/tds"
+)
+
+func TestEncodeDecode(t *testing.T) {
+	st := tds.NewStore2D()
+	v0, v1, v2 := tds.Vec2{0, 0}, tds.Vec2{1, 0}, tds.Vec2{1, 1}
+	if err := st.AddTriangle(v0, v1, v2); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := Encode(&buf, st); err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+
+	got, err := Decode(&buf)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if tris := nonGhostTriangles(got); len(tris) != 1 {
+		t.Fatalf("want 1 triangle, have %v", len(tris))
+	}
+}
+
+func TestEncodeDecodeZ(t *testing.T) {
+	st := tds.NewStore25D()
+	v0, v1, v2 := tds.Vec2{0, 0}, tds.Vec2{1, 0}, tds.Vec2{1, 1}
+	st.SetZ(v0, 1)
+	st.SetZ(v1, 2)
+	st.SetZ(v2, 3)
+	if err := st.AddTriangle(v0, v1, v2); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := EncodeZ(&buf, st); err != nil {
+		t.Fatalf("EncodeZ: %v", err)
+	}
+
+	got, err := DecodeZ(&buf)
+	if err != nil {
+		t.Fatalf("DecodeZ: %v", err)
+	}
+	if got.Z(v0) != 1 || got.Z(v1) != 2 || got.Z(v2) != 3 {
+		t.Errorf("want elevations 1,2,3, have %v,%v,%v", got.Z(v0), got.Z(v1), got.Z(v2))
+	}
+}
+
+func TestDecodeRejectsNonPolygonFeatures(t *testing.T) {
+	body := `{"type":"FeatureCollection","features":[{"type":"Feature","geometry":{"type":"Point","coordinates":[0,0]},"properties":{}}]}`
+	if _, err := Decode(bytes.NewBufferString(body)); err == nil {
+		t.Error("want an error decoding a non-Polygon feature")
+	}
+}
@@ -0,0 +1,377 @@
+package tds
+
+import "math/big"
+
+// This file implements the adaptive-precision geometric predicates described
+// in Shewchuk's "Adaptive Precision Floating-Point Arithmetic and Fast Robust
+// Geometric Predicates" (1997). Orient2D and InCircle in tds.go compute their
+// determinants directly in float32, so near-degenerate inputs (collinear or
+// cocircular points) can round to the wrong sign and send Store2D.dig into an
+// incorrect flip. The *Robust variants below fast-path the common case with a
+// float64 evaluation certified against an a-priori error bound, and only pay
+// for exact arithmetic when that bound can't rule out a sign flip.
+//
+// Unlike Orient2D/InCircle/Orient3D/InSphere, the robust variants only
+// report the sign of the determinant (-1, 0, or 1): every caller in this
+// package (dig, PointLocation) only ever tests sign, and an exact magnitude
+// would require carrying the full expansion or big.Float result back out as
+// a float32, which loses the precision that made it exact in the first
+// place.
+
+const epsilon = 1.1102230246251565e-16 // 2^-53, half the float64 machine epsilon
+const splitter = 134217729             // 2^27 + 1, used by twoProduct to split mantissas (Dekker)
+
+// twoSum computes x = round(a+b) and the rounding error y such that
+// a+b == x+y exactly, with no loss of precision (Knuth's TwoSum).
+func twoSum(a, b float64) (x, y float64) {
+	x = a + b
+	bv := x - a
+	y = (a - (x - bv)) + (b - bv)
+	return
+}
+
+// split breaks a into non-overlapping high and low halves, hi+lo == a, with
+// hi holding the top 26 significant bits (Dekker's algorithm).
+func split(a float64) (hi, lo float64) {
+	c := splitter * a
+	hi = c - (c - a)
+	lo = a - hi
+	return
+}
+
+// twoProduct computes x = round(a*b) and the rounding error y such that
+// a*b == x+y exactly.
+func twoProduct(a, b float64) (x, y float64) {
+	x = a * b
+	ahi, alo := split(a)
+	bhi, blo := split(b)
+	y = alo*blo - (((x - ahi*bhi) - alo*bhi) - ahi*blo)
+	return
+}
+
+// growExpansion adds the scalar b into e, a nonoverlapping expansion of
+// increasing magnitude, returning a new nonoverlapping expansion that
+// represents e+b exactly (the zero-eliminated grow-expansion from
+// Shewchuk's appendix).
+func growExpansion(e []float64, b float64) []float64 {
+	out := make([]float64, 0, len(e)+1)
+	q := b
+	for _, ei := range e {
+		var h float64
+		q, h = twoSum(q, ei)
+		if h != 0 {
+			out = append(out, h)
+		}
+	}
+	return append(out, q)
+}
+
+// expansionSum approximates the exact value an expansion represents. Because
+// the components are nonoverlapping and sorted by increasing magnitude, a
+// plain left-to-right sum rounds to the value nearest the exact result,
+// which is all sign() below needs.
+func expansionSum(e []float64) float64 {
+	var s float64
+	for _, ei := range e {
+		s += ei
+	}
+	return s
+}
+
+func sign(x float64) float32 {
+	switch {
+	case x > 0:
+		return 1
+	case x < 0:
+		return -1
+	default:
+		return 0
+	}
+}
+
+// signedArea2D returns twice the signed area of triangle a, b, c, computed
+// in float64. Orient2DRobust only certifies the determinant's sign (see the
+// comment at the top of this file), so callers that need the actual magnitude — such as
+// barycentric's weight ratios — use this instead of Orient2D's plain float32
+// determinant, which loses precision on thin or near-degenerate triangles.
+func signedArea2D(a, b, c Vec2) float64 {
+	acx := float64(a[0]) - float64(c[0])
+	bcx := float64(b[0]) - float64(c[0])
+	acy := float64(a[1]) - float64(c[1])
+	bcy := float64(b[1]) - float64(c[1])
+	return acx*bcy - acy*bcx
+}
+
+var ccwerrboundA = (3 + 16*epsilon) * epsilon
+
+// Orient2DRobust is the adaptive-precision counterpart of Orient2D: points
+// a, b, c are classified counter-clockwise (1), clockwise (-1), or collinear
+// (0), with the sign certified correct even when a, b, c are nearly
+// collinear.
+func Orient2DRobust(a, b, c Vec2) float32 {
+	acx := float64(a[0]) - float64(c[0])
+	bcx := float64(b[0]) - float64(c[0])
+	acy := float64(a[1]) - float64(c[1])
+	bcy := float64(b[1]) - float64(c[1])
+
+	detleft := acx * bcy
+	detright := acy * bcx
+	det := detleft - detright
+
+	var detsum float64
+	switch {
+	case detleft > 0:
+		if detright <= 0 {
+			return sign(det)
+		}
+		detsum = detleft + detright
+	case detleft < 0:
+		if detright >= 0 {
+			return sign(det)
+		}
+		detsum = -detleft - detright
+	default:
+		return sign(det)
+	}
+
+	errbound := ccwerrboundA * detsum
+	if det >= errbound || -det >= errbound {
+		return sign(det)
+	}
+
+	// The filter couldn't certify the sign: expand acx*bcy - acy*bcx into a
+	// nonoverlapping expansion via error-free two_product/two_sum and take
+	// the sign of that instead.
+	lx, ly := twoProduct(acx, bcy)
+	rx, ry := twoProduct(acy, bcx)
+	var e []float64
+	e = growExpansion(e, lx)
+	e = growExpansion(e, ly)
+	e = growExpansion(e, -rx)
+	e = growExpansion(e, -ry)
+	return sign(expansionSum(e))
+}
+
+var iccerrboundA = (10 + 96*epsilon) * epsilon
+
+// InCircleRobust is the adaptive-precision counterpart of InCircle: it
+// returns 1 if d lies strictly inside the circle through a, b, c (oriented
+// counter-clockwise), -1 if outside, or 0 if the four points are
+// cocircular, with the sign certified correct even when d sits close to the
+// circle's boundary.
+func InCircleRobust(a, b, c, d *Vec2) float32 {
+	adx := float64(a[0]) - float64(d[0])
+	ady := float64(a[1]) - float64(d[1])
+	bdx := float64(b[0]) - float64(d[0])
+	bdy := float64(b[1]) - float64(d[1])
+	cdx := float64(c[0]) - float64(d[0])
+	cdy := float64(c[1]) - float64(d[1])
+
+	bdxcdy := bdx * cdy
+	cdxbdy := cdx * bdy
+	alift := adx*adx + ady*ady
+
+	cdxady := cdx * ady
+	adxcdy := adx * cdy
+	blift := bdx*bdx + bdy*bdy
+
+	adxbdy := adx * bdy
+	bdxady := bdx * ady
+	clift := cdx*cdx + cdy*cdy
+
+	det := alift*(bdxcdy-cdxbdy) + blift*(cdxady-adxcdy) + clift*(adxbdy-bdxady)
+
+	permanent := (abs(bdxcdy)+abs(cdxbdy))*alift +
+		(abs(cdxady)+abs(adxcdy))*blift +
+		(abs(adxbdy)+abs(bdxady))*clift
+	errbound := iccerrboundA * permanent
+	if det > errbound || -det > errbound {
+		return sign(det)
+	}
+
+	return sign(incircleExact(a, b, c, d))
+}
+
+var orient3derrboundA = (7 + 56*epsilon) * epsilon
+
+// Orient3DRobust is the adaptive-precision counterpart of Orient3D.
+func Orient3DRobust(a, b, c, d Vec3) float32 {
+	adx, ady, adz := float64(a[0])-float64(d[0]), float64(a[1])-float64(d[1]), float64(a[2])-float64(d[2])
+	bdx, bdy, bdz := float64(b[0])-float64(d[0]), float64(b[1])-float64(d[1]), float64(b[2])-float64(d[2])
+	cdx, cdy, cdz := float64(c[0])-float64(d[0]), float64(c[1])-float64(d[1]), float64(c[2])-float64(d[2])
+
+	bdxcdy := bdx * cdy
+	cdxbdy := cdx * bdy
+	cdxady := cdx * ady
+	adxcdy := adx * cdy
+	adxbdy := adx * bdy
+	bdxady := bdx * ady
+
+	det := adz*(bdxcdy-cdxbdy) + bdz*(cdxady-adxcdy) + cdz*(adxbdy-bdxady)
+
+	permanent := (abs(bdxcdy)+abs(cdxbdy))*abs(adz) +
+		(abs(cdxady)+abs(adxcdy))*abs(bdz) +
+		(abs(adxbdy)+abs(bdxady))*abs(cdz)
+	errbound := orient3derrboundA * permanent
+	if det > errbound || -det > errbound {
+		return sign(det)
+	}
+
+	return sign(orient3dExact(a, b, c, d))
+}
+
+var isperrboundA = (16 + 224*epsilon) * epsilon
+
+// InSphereRobust is the adaptive-precision counterpart of InSphere.
+func InSphereRobust(a, b, c, d, e Vec3) float32 {
+	var m [4][4]float64
+	for i, p := range [4]Vec3{a, b, c, d} {
+		vx, vy, vz := float64(p[0])-float64(e[0]), float64(p[1])-float64(e[1]), float64(p[2])-float64(e[2])
+		m[i] = [4]float64{vx, vy, vz, vx*vx + vy*vy + vz*vz}
+	}
+
+	det := m[0][0]*(m[1][1]*(m[2][2]*m[3][3]-m[2][3]*m[3][2])-
+		m[1][2]*(m[2][1]*m[3][3]-m[2][3]*m[3][1])+
+		m[1][3]*(m[2][1]*m[3][2]-m[2][2]*m[3][1])) -
+		m[0][1]*(m[1][0]*(m[2][2]*m[3][3]-m[2][3]*m[3][2])-
+			m[1][2]*(m[2][0]*m[3][3]-m[2][3]*m[3][0])+
+			m[1][3]*(m[2][0]*m[3][2]-m[2][2]*m[3][0])) +
+		m[0][2]*(m[1][0]*(m[2][1]*m[3][3]-m[2][3]*m[3][1])-
+			m[1][1]*(m[2][0]*m[3][3]-m[2][3]*m[3][0])+
+			m[1][3]*(m[2][0]*m[3][1]-m[2][1]*m[3][0])) -
+		m[0][3]*(m[1][0]*(m[2][1]*m[3][2]-m[2][2]*m[3][1])-
+			m[1][1]*(m[2][0]*m[3][2]-m[2][2]*m[3][0])+
+			m[1][2]*(m[2][0]*m[3][1]-m[2][1]*m[3][0]))
+
+	var permanent float64
+	for _, row := range m {
+		permanent += abs(row[0]) + abs(row[1]) + abs(row[2]) + abs(row[3])
+	}
+	errbound := isperrboundA * permanent * permanent * permanent
+	if det > errbound || -det > errbound {
+		return sign(det)
+	}
+
+	return sign(insphereExact(a, b, c, d, e))
+}
+
+func abs(x float64) float64 {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+// bigPrec is the working precision used by the big.Float fallback below.
+// InCircle/Orient3D/InSphere are degree 3-5 polynomials in the input
+// coordinates; a hobby-scale repo doesn't need Shewchuk's full
+// macro-expanded expansion arithmetic to certify these exactly when a
+// generous fixed-precision big.Float evaluation gets there with far less
+// code, at the cost of no longer being a true symbolic expansion.
+const bigPrec = 512
+
+func bigf(x float32) *big.Float {
+	return new(big.Float).SetPrec(bigPrec).SetFloat64(float64(x))
+}
+
+func incircleExact(a, b, c, d *Vec2) float64 {
+	ax, ay := bigf(a[0]), bigf(a[1])
+	bx, by := bigf(b[0]), bigf(b[1])
+	cx, cy := bigf(c[0]), bigf(c[1])
+	dx, dy := bigf(d[0]), bigf(d[1])
+
+	sub := func(x, y *big.Float) *big.Float { return new(big.Float).SetPrec(bigPrec).Sub(x, y) }
+	mul := func(x, y *big.Float) *big.Float { return new(big.Float).SetPrec(bigPrec).Mul(x, y) }
+	add := func(x, y *big.Float) *big.Float { return new(big.Float).SetPrec(bigPrec).Add(x, y) }
+
+	adx, ady := sub(ax, dx), sub(ay, dy)
+	bdx, bdy := sub(bx, dx), sub(by, dy)
+	cdx, cdy := sub(cx, dx), sub(cy, dy)
+
+	alift := add(mul(adx, adx), mul(ady, ady))
+	blift := add(mul(bdx, bdx), mul(bdy, bdy))
+	clift := add(mul(cdx, cdx), mul(cdy, cdy))
+
+	t0 := mul(alift, sub(mul(bdx, cdy), mul(cdx, bdy)))
+	t1 := mul(blift, sub(mul(cdx, ady), mul(adx, cdy)))
+	t2 := mul(clift, sub(mul(adx, bdy), mul(bdx, ady)))
+
+	det, _ := add(add(t0, t1), t2).Float64()
+	return det
+}
+
+func orient3dExact(a, b, c, d Vec3) float64 {
+	ax, ay, az := bigf(a[0]), bigf(a[1]), bigf(a[2])
+	bx, by, bz := bigf(b[0]), bigf(b[1]), bigf(b[2])
+	cx, cy, cz := bigf(c[0]), bigf(c[1]), bigf(c[2])
+	dx, dy, dz := bigf(d[0]), bigf(d[1]), bigf(d[2])
+
+	sub := func(x, y *big.Float) *big.Float { return new(big.Float).SetPrec(bigPrec).Sub(x, y) }
+	mul := func(x, y *big.Float) *big.Float { return new(big.Float).SetPrec(bigPrec).Mul(x, y) }
+	add := func(x, y *big.Float) *big.Float { return new(big.Float).SetPrec(bigPrec).Add(x, y) }
+
+	adx, ady, adz := sub(ax, dx), sub(ay, dy), sub(az, dz)
+	bdx, bdy, bdz := sub(bx, dx), sub(by, dy), sub(bz, dz)
+	cdx, cdy, cdz := sub(cx, dx), sub(cy, dy), sub(cz, dz)
+
+	t0 := mul(adz, sub(mul(bdx, cdy), mul(cdx, bdy)))
+	t1 := mul(bdz, sub(mul(cdx, ady), mul(adx, cdy)))
+	t2 := mul(cdz, sub(mul(adx, bdy), mul(bdx, ady)))
+
+	det, _ := add(add(t0, t1), t2).Float64()
+	return det
+}
+
+func insphereExact(a, b, c, d, e Vec3) float64 {
+	pt := [4][3]*big.Float{}
+	for i, p := range [4]Vec3{a, b, c, d} {
+		pt[i] = [3]*big.Float{bigf(p[0]), bigf(p[1]), bigf(p[2])}
+	}
+	ex, ey, ez := bigf(e[0]), bigf(e[1]), bigf(e[2])
+
+	sub := func(x, y *big.Float) *big.Float { return new(big.Float).SetPrec(bigPrec).Sub(x, y) }
+	mul := func(x, y *big.Float) *big.Float { return new(big.Float).SetPrec(bigPrec).Mul(x, y) }
+	add := func(x, y *big.Float) *big.Float { return new(big.Float).SetPrec(bigPrec).Add(x, y) }
+
+	var v [4][4]*big.Float
+	for i, p := range pt {
+		vx, vy, vz := sub(p[0], ex), sub(p[1], ey), sub(p[2], ez)
+		lift := add(add(mul(vx, vx), mul(vy, vy)), mul(vz, vz))
+		v[i] = [4]*big.Float{vx, vy, vz, lift}
+	}
+
+	det3 := func(m [3][3]*big.Float) *big.Float {
+		return sub(
+			add(mul(m[0][0], sub(mul(m[1][1], m[2][2]), mul(m[1][2], m[2][1]))),
+				mul(m[0][2], sub(mul(m[1][0], m[2][1]), mul(m[1][1], m[2][0])))),
+			mul(m[0][1], sub(mul(m[1][0], m[2][2]), mul(m[1][2], m[2][0]))),
+		)
+	}
+
+	// Laplace expansion along column 0: det = sum_row (-1)^row * v[row][0] * minor(row,0).
+	var det *big.Float
+	for row := 0; row < 4; row++ {
+		var m [3][3]*big.Float
+		r := 0
+		for k := 0; k < 4; k++ {
+			if k == row {
+				continue
+			}
+			m[r] = [3]*big.Float{v[k][1], v[k][2], v[k][3]}
+			r++
+		}
+		cof := det3(m)
+		if row%2 == 1 {
+			cof = new(big.Float).SetPrec(bigPrec).Neg(cof)
+		}
+		term := mul(v[row][0], cof)
+		if det == nil {
+			det = term
+		} else {
+			det = add(det, term)
+		}
+	}
+
+	f, _ := det.Float64()
+	return f
+}
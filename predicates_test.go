@@ -0,0 +1,80 @@
+package tds
+
+import "testing"
+
+func TestOrient2DRobust(t *testing.T) {
+	v0, v1, v2 := Vec2{0, 0}, Vec2{1, 0}, Vec2{1, 1}
+	if x := Orient2DRobust(v0, v1, v2); x != 1 {
+		t.Errorf("want 1, have %v", x)
+	}
+	if x := Orient2DRobust(v0, v2, v1); x != -1 {
+		t.Errorf("want -1, have %v", x)
+	}
+	if x := Orient2DRobust(v0, v1, v0); x != 0 {
+		t.Errorf("want 0, have %v", x)
+	}
+
+	// Exactly collinear points with large, non-round coordinates must
+	// report collinear (0) under the robust path.
+	a := Vec2{0, 0}
+	b := Vec2{12345.6789, 9876.54321}
+	c := Vec2{24691.3578, 19753.08642} // c == 2*b, exactly collinear with a, b
+	if x := Orient2DRobust(a, b, c); x != 0 {
+		t.Errorf("want 0 for collinear points, have %v", x)
+	}
+}
+
+func TestInCircleRobust(t *testing.T) {
+	v0, v1, v2 := &Vec2{0, 0}, &Vec2{1, 0}, &Vec2{1, 1}
+	if x := InCircleRobust(v0, v1, v2, &Vec2{0.5, 0.5}); x != 1 {
+		t.Errorf("want 1, have %v", x)
+	}
+	if x := InCircleRobust(v0, v1, v2, &Vec2{-1, 0}); x != -1 {
+		t.Errorf("want -1, have %v", x)
+	}
+	// {0,1} is exactly cocircular with v0, v1, v2 on the circle of radius
+	// sqrt(0.5) centered at (0.5, 0.5); this is the case float32 arithmetic is prone
+	// to misclassify.
+	if x := InCircleRobust(v0, v1, v2, &Vec2{0, 1}); x != 0 {
+		t.Errorf("want 0, have %v", x)
+	}
+}
+
+func TestOrient3DRobust(t *testing.T) {
+	v0, v1, v2, v3 := Vec3{0, 1, 0}, Vec3{0, 0, 1}, Vec3{1, 0, 0}, Vec3{0, 0, -1}
+	if x := Orient3DRobust(v0, v1, v2, v3); x != 1 {
+		t.Errorf("want 1, have %v", x)
+	}
+	if x := Orient3DRobust(v0, v2, v1, v3); x != -1 {
+		t.Errorf("want -1, have %v", x)
+	}
+	if x := Orient3DRobust(v0, v1, v0, v3); x != 0 {
+		t.Errorf("want 0, have %v", x)
+	}
+}
+
+func TestInSphereRobust(t *testing.T) {
+	v0, v1, v2, v3 := Vec3{0, 1, 0}, Vec3{0, 0, 1}, Vec3{1, 0, 0}, Vec3{0, 0, -1}
+	if x := InSphereRobust(v0, v1, v2, v3, Vec3{1, 1, 1}); x != -1 {
+		t.Errorf("want -1, have %v", x)
+	}
+	if x := InSphereRobust(v0, v1, v2, v3, Vec3{0, 0, 0}); x != 1 {
+		t.Errorf("want 1, have %v", x)
+	}
+	if x := InSphereRobust(v0, v1, v2, v3, Vec3{0, -1, 0}); x != 0 {
+		t.Errorf("want 0, have %v", x)
+	}
+
+	// Large-magnitude coordinates that are known to confidently flip sign
+	// when the fast-path determinant is evaluated in float32 rather than
+	// float64 (the lift term dx*dx+dy*dy+dz*dz loses precision well before
+	// errbound's float64-epsilon-derived tolerance is meaningful).
+	a := Vec3{114702.195, 121475.14, 137766.83}
+	b := Vec3{94258.64, 164188.95, 112679.805}
+	c := Vec3{55855.51, 130877.44, 69192.66}
+	d := Vec3{82415.42, 88738.49, 124835.336}
+	e := Vec3{135418.38, 84738.02, 88540.02}
+	if x, want := InSphereRobust(a, b, c, d, e), sign(insphereExact(a, b, c, d, e)); x != want {
+		t.Errorf("want %v (matching the exact fallback), have %v", want, x)
+	}
+}
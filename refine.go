@@ -0,0 +1,246 @@
+package tds
+
+import (
+	"fmt"
+	"math"
+)
+
+// This file implements Ruppert's Delaunay refinement algorithm: Refine
+// inserts Steiner points until every non-ghost triangle meets a minimum
+// angle bound, splitting constrained segments at their midpoint instead of
+// inserting a circumcenter that would encroach them, which is what
+// guarantees the algorithm terminates.
+
+// RefineOptions configures Store2D.Refine.
+type RefineOptions struct {
+	// MinAngle is the minimum interior angle, in radians, Refine tries to
+	// achieve for every triangle. Zero selects the default, ~20.7 degrees,
+	// the largest bound Ruppert's algorithm is proven to terminate for.
+	MinAngle float32
+	// MaxIterations caps the number of queue entries Refine will process,
+	// whether each ends in a segment split, a vertex insertion, or is
+	// skipped as stale. Zero selects a generous default; it exists to
+	// bound runaway refinement on inputs that violate the MinAngle
+	// termination guarantee (e.g. an input with two constrained segments
+	// meeting at less than MinAngle) rather than to be tuned routinely.
+	MaxIterations int
+}
+
+const defaultMinAngle = 0.3612831551628262 // ~20.7 degrees, in radians
+const defaultMaxIterations = 10000
+
+func sinf(x float32) float32 {
+	return float32(math.Sin(float64(x)))
+}
+
+// finite reports whether both components of v are finite, non-NaN values.
+// circumcenter can return extreme or non-finite coordinates for a
+// near-degenerate triangle (its denominator approaches zero), and those
+// aren't safe to hand to the predicates, which lift coordinates by squaring
+// them.
+func finite(v Vec2) bool {
+	for _, c := range v {
+		f := float64(c)
+		if math.IsInf(f, 0) || math.IsNaN(f) {
+			return false
+		}
+	}
+	return true
+}
+
+// circumcenter returns the center of the circle through a, b, and c.
+func circumcenter(a, b, c Vec2) Vec2 {
+	ax, ay := float64(a[0]), float64(a[1])
+	bx, by := float64(b[0]), float64(b[1])
+	cx, cy := float64(c[0]), float64(c[1])
+
+	d := 2 * (ax*(by-cy) + bx*(cy-ay) + cx*(ay-by))
+	asq, bsq, csq := ax*ax+ay*ay, bx*bx+by*by, cx*cx+cy*cy
+
+	x := (asq*(by-cy) + bsq*(cy-ay) + csq*(ay-by)) / d
+	y := (asq*(cx-bx) + bsq*(ax-cx) + csq*(bx-ax)) / d
+	return Vec2{float32(x), float32(y)}
+}
+
+// encroaches reports whether r lies inside the diametral circle of segment
+// p-q, i.e. whether angle p-r-q is obtuse.
+func encroaches(p, q, r Vec2) bool {
+	var rp, rq Vec2
+	rp.Sub(&p, &r)
+	rq.Sub(&q, &r)
+	return rp.Dot(&rq) < 0
+}
+
+// segments returns each constrained edge once, regardless of which of its
+// two directions was used to mark it.
+func (st *Store2D) segments() []Mat2 {
+	seen := make(map[Mat2]bool)
+	var out []Mat2
+	for e := range st.c {
+		if seen[Mat2{e[1], e[0]}] {
+			continue
+		}
+		seen[e] = true
+		out = append(out, e)
+	}
+	return out
+}
+
+// VertexSet returns every non-ghost vertex currently in the triangulation.
+func (st *Store2D) VertexSet() []Vec2 {
+	return st.vertices()
+}
+
+// vertices returns every non-ghost vertex currently in the triangulation.
+func (st *Store2D) vertices() []Vec2 {
+	seen := make(map[Vec2]bool)
+	var out []Vec2
+	for t := range st.tri {
+		for _, p := range t {
+			if p == GhostVertex || seen[p] {
+				continue
+			}
+			seen[p] = true
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// findEncroachedBy returns a constrained segment whose diametral circle
+// contains r, if any.
+func (st *Store2D) findEncroachedBy(r Vec2) (Mat2, bool) {
+	for _, seg := range st.segments() {
+		if encroaches(seg[0], seg[1], r) {
+			return seg, true
+		}
+	}
+	return Mat2{}, false
+}
+
+// encroachedSegments returns every constrained segment currently encroached
+// upon by some other vertex in the triangulation.
+func (st *Store2D) encroachedSegments() []Mat2 {
+	verts := st.vertices()
+	var out []Mat2
+	for _, seg := range st.segments() {
+		for _, r := range verts {
+			if r == seg[0] || r == seg[1] {
+				continue
+			}
+			if encroaches(seg[0], seg[1], r) {
+				out = append(out, seg)
+				break
+			}
+		}
+	}
+	return out
+}
+
+// skinnyTriangles returns every non-ghost triangle with an angle whose sine
+// is below minSin.
+func (st *Store2D) skinnyTriangles(minSin float32) []Triangle {
+	var out []Triangle
+	for t := range st.tri {
+		u, v, w := t[0], t[1], t[2]
+		if u == GhostVertex || v == GhostVertex || w == GhostVertex {
+			continue
+		}
+		if anglerads(u, v, w) < minSin || anglerads(v, w, u) < minSin || anglerads(w, u, v) < minSin {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// splitSegment replaces the constrained edge p-q with p-mid and mid-q,
+// inserting mid as a new triangulation vertex.
+func (st *Store2D) splitSegment(p, q, mid Vec2) error {
+	delete(st.c, Mat2{p, q})
+	delete(st.c, Mat2{q, p})
+
+	u, v, w, err := st.PointLocation(mid)
+	if err != nil {
+		return err
+	}
+	if err := st.InsertVertex(mid, u, v, w); err != nil {
+		return err
+	}
+	if err := st.InsertSegment(p, mid); err != nil {
+		return err
+	}
+	return st.InsertSegment(mid, q)
+}
+
+// Refine applies Ruppert's algorithm: it repeatedly splits encroached
+// constrained segments at their midpoint and inserts the circumcenter of
+// skinny triangles (falling back to a segment split when that circumcenter
+// would itself encroach a segment), until no work remains in its queues or
+// opts.MaxIterations is exhausted. A skinny triangle whose circumcenter is
+// non-finite or lies outside the triangulated region is skipped, so it can
+// remain below opts.MinAngle when Refine returns nil.
+func (st *Store2D) Refine(opts RefineOptions) error {
+	minAngle := opts.MinAngle
+	if minAngle <= 0 {
+		minAngle = defaultMinAngle
+	}
+	minSin := sinf(minAngle)
+
+	maxIter := opts.MaxIterations
+	if maxIter <= 0 {
+		maxIter = defaultMaxIterations
+	}
+
+	segQueue := st.encroachedSegments()
+	triQueue := st.skinnyTriangles(minSin)
+
+	for i := 0; len(segQueue) > 0 || len(triQueue) > 0; i++ {
+		if i >= maxIter {
+			return fmt.Errorf("tds: Refine did not converge within %d iterations", maxIter)
+		}
+
+		if len(segQueue) > 0 {
+			seg := segQueue[0]
+			segQueue = segQueue[1:]
+			if !st.constrained(seg[0], seg[1]) {
+				continue // already resolved by an earlier split
+			}
+			mid := Vec2{(seg[0][0] + seg[1][0]) / 2, (seg[0][1] + seg[1][1]) / 2}
+			if err := st.splitSegment(seg[0], seg[1], mid); err != nil {
+				return err
+			}
+			segQueue = append(segQueue, st.encroachedSegments()...)
+			triQueue = append(triQueue, st.skinnyTriangles(minSin)...)
+			continue
+		}
+
+		t := triQueue[0]
+		triQueue = triQueue[1:]
+		if !st.tri[t] {
+			continue // already resolved by an earlier insertion
+		}
+		cc := circumcenter(t[0], t[1], t[2])
+		if !finite(cc) {
+			// t is ill-conditioned enough that its circumcenter isn't
+			// representable in float32; skip it rather than hand a
+			// non-finite point to the predicates.
+			continue
+		}
+
+		if seg, ok := st.findEncroachedBy(cc); ok {
+			segQueue = append(segQueue, seg)
+			continue
+		}
+
+		u, v, w, err := st.PointLocation(cc)
+		if err != nil {
+			continue // circumcenter falls outside the triangulated region
+		}
+		if err := st.InsertVertex(cc, u, v, w); err != nil {
+			return err
+		}
+		segQueue = append(segQueue, st.encroachedSegments()...)
+		triQueue = append(triQueue, st.skinnyTriangles(minSin)...)
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package tds
+
+import "testing"
+
+func TestEncroaches(t *testing.T) {
+	p, q := Vec2{0, 0}, Vec2{2, 0}
+	if !encroaches(p, q, Vec2{1, 0.5}) {
+		t.Error("want point inside the diametral circle to encroach")
+	}
+	if encroaches(p, q, Vec2{1, 5}) {
+		t.Error("want point outside the diametral circle not to encroach")
+	}
+}
+
+func TestCircumcenter(t *testing.T) {
+	cc := circumcenter(Vec2{0, 0}, Vec2{1, 0}, Vec2{0, 1})
+	if cc != (Vec2{0.5, 0.5}) {
+		t.Errorf("want {0.5 0.5}, have %v", cc)
+	}
+}
+
+func TestStore2DRefineSkinnyTriangle(t *testing.T) {
+	st, _ := gridStore2D(t)
+
+	opts := RefineOptions{MinAngle: 0.5}
+	if err := st.Refine(opts); err != nil {
+		t.Fatalf("Refine: %v", err)
+	}
+	if skinny := st.skinnyTriangles(sinf(opts.MinAngle)); len(skinny) != 0 {
+		t.Errorf("want no triangles below the angle bound after refinement, have %v", len(skinny))
+	}
+}
+
+// rectangleFan builds a rectangle split into 4 triangles around an interior
+// point, used to exercise segment splitting without the split's midpoint
+// coincidentally falling on an existing edge.
+func rectangleFan(t *testing.T) (st *Store2D, p0, p2 Vec2) {
+	t.Helper()
+	st = NewStore2D()
+	p0, p1, p2, p3 := Vec2{0, 0}, Vec2{10, 0}, Vec2{10, 6}, Vec2{0, 6}
+	p4 := Vec2{6, 2}
+	for _, tr := range [][3]Vec2{{p0, p1, p4}, {p1, p2, p4}, {p2, p3, p4}, {p3, p0, p4}} {
+		if err := st.AddTriangle(tr[0], tr[1], tr[2]); err != nil {
+			t.Fatalf("AddTriangle(%v): %v", tr, err)
+		}
+	}
+	return st, p0, p2
+}
+
+func TestStore2DRefineEncroachedSegment(t *testing.T) {
+	st, p0, p2 := rectangleFan(t)
+	if err := st.InsertSegment(p0, p2); err != nil {
+		t.Fatalf("InsertSegment: %v", err)
+	}
+	if len(st.encroachedSegments()) == 0 {
+		t.Fatal("want the diagonal encroached by the interior fan point before refining")
+	}
+
+	opts := RefineOptions{MinAngle: 0.3}
+	if err := st.Refine(opts); err != nil {
+		t.Fatalf("Refine: %v", err)
+	}
+	if encroached := st.encroachedSegments(); len(encroached) != 0 {
+		t.Errorf("want no encroached segments after refinement, have %v", len(encroached))
+	}
+	if skinny := st.skinnyTriangles(sinf(opts.MinAngle)); len(skinny) != 0 {
+		t.Errorf("want no triangles below the angle bound after refinement, have %v", len(skinny))
+	}
+}
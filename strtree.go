@@ -0,0 +1,233 @@
+package tds
+
+import (
+	"math"
+	"sort"
+)
+
+// Triangle is a triangle's three vertices in the order they were passed to
+// Store2D.AddTriangle.
+type Triangle [3]Vec2
+
+func (t Triangle) bbox() (min, max Vec2) {
+	min, max = t[0], t[0]
+	for _, p := range t[1:] {
+		if p[0] < min[0] {
+			min[0] = p[0]
+		}
+		if p[1] < min[1] {
+			min[1] = p[1]
+		}
+		if p[0] > max[0] {
+			max[0] = p[0]
+		}
+		if p[1] > max[1] {
+			max[1] = p[1]
+		}
+	}
+	return
+}
+
+// centroid returns a point guaranteed to lie strictly inside t (unlike its
+// bbox's midpoint, which can fall outside an obtuse triangle).
+func (t Triangle) centroid() Vec2 {
+	return Vec2{(t[0][0] + t[1][0] + t[2][0]) / 3, (t[0][1] + t[1][1] + t[2][1]) / 3}
+}
+
+// strLeafCap is the branching factor used when packing the STRtree: every
+// packed node holds at most strLeafCap children. Each leaf holds a single
+// triangle.
+const strLeafCap = 8
+
+// strNode is one node of a bulk-loaded Sort-Tile-Recursive R-tree. Leaf
+// nodes carry triangles directly; interior nodes only carry children.
+type strNode struct {
+	min, max Vec2
+	tris     []Triangle
+	children []*strNode
+}
+
+func (n *strNode) contains(u Vec2) bool {
+	return u[0] >= n.min[0] && u[0] <= n.max[0] && u[1] >= n.min[1] && u[1] <= n.max[1]
+}
+
+func (n *strNode) overlaps(min, max Vec2) bool {
+	return n.max[0] >= min[0] && n.min[0] <= max[0] && n.max[1] >= min[1] && n.min[1] <= max[1]
+}
+
+// queryPoint visits every triangle whose bbox contains u, stopping early if
+// fn returns true.
+func (n *strNode) queryPoint(u Vec2, fn func(Triangle) bool) bool {
+	if n == nil || !n.contains(u) {
+		return false
+	}
+	if n.tris != nil {
+		for _, t := range n.tris {
+			if fn(t) {
+				return true
+			}
+		}
+		return false
+	}
+	for _, c := range n.children {
+		if c.queryPoint(u, fn) {
+			return true
+		}
+	}
+	return false
+}
+
+func (n *strNode) queryBox(min, max Vec2, out *[]Triangle) {
+	if n == nil || !n.overlaps(min, max) {
+		return
+	}
+	if n.tris != nil {
+		for _, t := range n.tris {
+			tmin, tmax := t.bbox()
+			if tmax[0] >= min[0] && tmin[0] <= max[0] && tmax[1] >= min[1] && tmin[1] <= max[1] {
+				*out = append(*out, t)
+			}
+		}
+		return
+	}
+	for _, c := range n.children {
+		c.queryBox(min, max, out)
+	}
+}
+
+// strBulkLoad packs tris into an STRtree: each triangle gets its own leaf,
+// then nodes are sorted along x into ceil(sqrt(n/strLeafCap)) vertical
+// slices, each slice sorted along y and tiled into parents of at most
+// strLeafCap children, and the resulting parents are packed the same way,
+// level by level, until a single root node remains.
+func strBulkLoad(tris []Triangle) *strNode {
+	if len(tris) == 0 {
+		return nil
+	}
+	nodes := make([]*strNode, len(tris))
+	for i, t := range tris {
+		min, max := t.bbox()
+		nodes[i] = &strNode{min: min, max: max, tris: []Triangle{t}}
+	}
+	for len(nodes) > 1 {
+		nodes = strPackLevel(nodes)
+	}
+	return nodes[0]
+}
+
+func strPackLevel(nodes []*strNode) []*strNode {
+	n := len(nodes)
+	numSlices := int(math.Ceil(math.Sqrt(float64(n) / float64(strLeafCap))))
+	if numSlices < 1 {
+		numSlices = 1
+	}
+	sliceCap := int(math.Ceil(float64(n) / float64(numSlices)))
+
+	sort.Slice(nodes, func(i, j int) bool { return centerOf(nodes[i])[0] < centerOf(nodes[j])[0] })
+
+	var parents []*strNode
+	for i := 0; i < n; i += sliceCap {
+		end := i + sliceCap
+		if end > n {
+			end = n
+		}
+		slice := nodes[i:end]
+		sort.Slice(slice, func(a, b int) bool { return centerOf(slice[a])[1] < centerOf(slice[b])[1] })
+		for j := 0; j < len(slice); j += strLeafCap {
+			k := j + strLeafCap
+			if k > len(slice) {
+				k = len(slice)
+			}
+			parents = append(parents, packParent(slice[j:k]))
+		}
+	}
+	return parents
+}
+
+func centerOf(n *strNode) Vec2 {
+	return Vec2{(n.min[0] + n.max[0]) / 2, (n.min[1] + n.max[1]) / 2}
+}
+
+func packParent(children []*strNode) *strNode {
+	p := &strNode{children: append([]*strNode{}, children...), min: children[0].min, max: children[0].max}
+	for _, c := range children[1:] {
+		if c.min[0] < p.min[0] {
+			p.min[0] = c.min[0]
+		}
+		if c.min[1] < p.min[1] {
+			p.min[1] = c.min[1]
+		}
+		if c.max[0] > p.max[0] {
+			p.max[0] = c.max[0]
+		}
+		if c.max[1] > p.max[1] {
+			p.max[1] = c.max[1]
+		}
+	}
+	return p
+}
+
+// Rebuild bulk-loads the STRtree from the triangles currently in st. Callers
+// don't normally need this: AddTriangle/DeleteTriangle track how far the
+// index has drifted and PointLocation/TrianglesIn rebuild it lazily, via
+// ensureIndex, once that drift gets expensive to keep working around. It's
+// exported for callers that want to pay the rebuild cost up front, e.g.
+// before a batch of point locations.
+func (st *Store2D) Rebuild() {
+	tris := make([]Triangle, 0, len(st.tri))
+	for t := range st.tri {
+		tris = append(tris, t)
+	}
+	st.strtree = strBulkLoad(tris)
+	st.pending = nil
+	st.changesSinceBuild = 0
+	st.builtSize = len(tris)
+}
+
+// ensureIndex rebuilds the STRtree if it's never been built, or if mutations
+// since the last build have piled up past half the indexed triangle count
+// plus strLeafCap.
+// This is the classic amortized ("global rebuilding") scheme for decomposable
+// search structures: a single AddTriangle/DeleteTriangle only ever appends to
+// st.pending or bumps a counter (O(1)), so n mutations cost O(n log n) total
+// across the occasional full rebuild rather than O(n) separate O(n log n)
+// rebuilds, one per mutation.
+func (st *Store2D) ensureIndex() {
+	if st.strtree == nil || st.changesSinceBuild > st.builtSize/2+strLeafCap {
+		st.Rebuild()
+	}
+}
+
+// TrianglesIn returns every triangle whose bounding box overlaps the box
+// spanned by min and max.
+func (st *Store2D) TrianglesIn(min, max Vec2) []Triangle {
+	st.ensureIndex()
+	var out []Triangle
+	st.strtree.queryBox(min, max, &out)
+	for _, t := range st.pending {
+		tmin, tmax := t.bbox()
+		if tmax[0] >= min[0] && tmin[0] <= max[0] && tmax[1] >= min[1] && tmin[1] <= max[1] {
+			out = append(out, t)
+		}
+	}
+
+	seen := make(map[Triangle]bool, len(out))
+	kept := out[:0]
+	for _, t := range out {
+		if st.tri[t] && !seen[t] {
+			seen[t] = true
+			kept = append(kept, t)
+		}
+	}
+	return kept
+}
+
+// Triangles returns every triangle currently in the triangulation, including
+// the ones incident to GhostVertex.
+func (st *Store2D) Triangles() []Triangle {
+	out := make([]Triangle, 0, len(st.tri))
+	for t := range st.tri {
+		out = append(out, t)
+	}
+	return out
+}
@@ -0,0 +1,50 @@
+package tds
+
+import "testing"
+
+func TestStore2DPointLocationAndTrianglesIn(t *testing.T) {
+	st := NewStore2D()
+	v0, v1, v2, v3 := Vec2{0, 0}, Vec2{1, 0}, Vec2{1, 1}, Vec2{0, 1}
+	if err := st.AddTriangle(v0, v1, v2); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.AddTriangle(v0, v2, v3); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, _, err := st.PointLocation(Vec2{0.5, 0.5}); err != nil {
+		t.Errorf("expected a triangle's circumdisk to contain the center point: %v", err)
+	}
+
+	got := st.TrianglesIn(Vec2{0, 0}, Vec2{1, 1})
+	if len(got) != 2 {
+		t.Errorf("want 2 triangles overlapping the unit box, have %v", len(got))
+	}
+
+	if got := st.TrianglesIn(Vec2{5, 5}, Vec2{6, 6}); len(got) != 0 {
+		t.Errorf("want no triangles overlapping a disjoint box, have %v", len(got))
+	}
+}
+
+// TestStore2DPointLocationFallback exercises PointLocation's full-scan
+// fallback: a near-collinear (sliver) triangle has a circumdisk that
+// extends far outside its own bounding box, so the STR-tree's bbox-only
+// query misses a point that's still inside the circumdisk, and only the
+// fallback scan over st.tri finds it.
+func TestStore2DPointLocationFallback(t *testing.T) {
+	st := NewStore2D()
+	a, b, c := Vec2{0, 0}, Vec2{10, 0}, Vec2{5, 0.1}
+	if err := st.AddTriangle(a, b, c); err != nil {
+		t.Fatal(err)
+	}
+	u := Vec2{5, -2}
+
+	st.ensureIndex()
+	if st.strtree.queryPoint(u, func(t Triangle) bool { return liftedContains(t[0], t[1], t[2], u) }) {
+		t.Fatal("expected the bbox-only STR-tree query to miss u, invalidating this test")
+	}
+
+	if _, _, _, err := st.PointLocation(u); err != nil {
+		t.Errorf("want the fallback scan to find u's containing circumdisk: %v", err)
+	}
+}
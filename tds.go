@@ -3,7 +3,6 @@ package tds
 
 import (
 	"fmt"
-	"log"
 	"math"
 )
 
@@ -186,15 +185,27 @@ type Store2D struct {
 	m map[Mat2]Mat2
 	g map[[3]Vec2][]Vec2
 	v map[[3]Vec2]bool
+
+	tri     map[Triangle]bool
+	strtree *strNode
+	c       map[Mat2]bool
+
+	// pending, changesSinceBuild, and builtSize support the STRtree's
+	// amortized rebuild scheme; see ensureIndex in strtree.go.
+	pending           []Triangle
+	changesSinceBuild int
+	builtSize         int
 }
 
 func (st *Store2D) M() map[Mat2]Mat2 { return st.m }
 
 func NewStore2D() *Store2D {
 	return &Store2D{
-		m: make(map[Mat2]Mat2),
-		g: make(map[[3]Vec2][]Vec2),
-		v: make(map[[3]Vec2]bool),
+		m:   make(map[Mat2]Mat2),
+		g:   make(map[[3]Vec2][]Vec2),
+		v:   make(map[[3]Vec2]bool),
+		tri: make(map[Triangle]bool),
+		c:   make(map[Mat2]bool),
 	}
 }
 
@@ -221,6 +232,10 @@ func (st *Store2D) AddTriangle(u, v, w Vec2) error {
 	st.m[e0] = e1
 	st.m[e1] = e2
 	st.m[e2] = e0
+	t := Triangle{u, v, w}
+	st.tri[t] = true
+	st.pending = append(st.pending, t)
+	st.changesSinceBuild++
 	return nil
 }
 
@@ -240,6 +255,14 @@ func (st *Store2D) DeleteTriangle(u, v, w Vec2) error {
 	delete(st.m, e0)
 	delete(st.m, e1)
 	delete(st.m, e2)
+	// AddTriangle's caller picks which of the triangle's 3 vertices comes
+	// first; a caller deleting the same triangle (e.g. dig, walking the
+	// mesh from a different vertex) may pass a different rotation, so try
+	// all 3 rather than only the exact (u, v, w) given here.
+	delete(st.tri, Triangle{u, v, w})
+	delete(st.tri, Triangle{v, w, u})
+	delete(st.tri, Triangle{w, u, v})
+	st.changesSinceBuild++
 	return nil
 }
 
@@ -248,20 +271,46 @@ func (st *Store2D) Adjacent(u, v Vec2) (Vec2, bool) {
 	return m[1], ok
 }
 
-const weight = 0.001
+func liftedContains(v, w, x, u Vec2) bool {
+	return InCircleRobust(&v, &w, &x, &u) > 0
+}
 
-// PointLocation returns one triangle whose open circumdisk contains u.
+// PointLocation returns one triangle whose open circumdisk contains u. It
+// queries the STR tree for triangles whose own bounding box contains u and
+// runs the robust in-circle test only on those, which is enough to avoid
+// scanning every edge in st.m for the common case where the insertion point
+// lands inside (or very near) the triangle whose circumdisk it belongs to.
+// Because ensureIndex amortizes full rebuilds (see strtree.go), the tree can
+// lag st.tri, so a candidate it returns is checked against st.tri before
+// being trusted, and st.pending (triangles added since the last rebuild) is
+// searched next. Because a triangle's circumdisk can also extend beyond its
+// own bbox, PointLocation finally falls back to a full scan of st.tri when
+// those come up empty, so it still finds a valid candidate the bbox-only
+// query missed.
 func (st *Store2D) PointLocation(u Vec2) (Vec2, Vec2, Vec2, error) {
-	for e0, e1 := range st.m {
-		v, w, x := e0[0], e1[0], e1[1]
-		v3 := Vec3{v[0], v[1], v.Dot(&v) - weight}
-		w3 := Vec3{w[0], w[1], w.Dot(&w) - weight}
-		x3 := Vec3{x[0], x[1], x.Dot(&x) - weight}
-		u3 := Vec3{u[0], u[1], u.Dot(&u) - weight}
-		// log.Println(Orient3D(v3, w3, x3, u3))
-		if Orient3D(v3, w3, x3, u3) > 0 {
-			// if InCircle(&v, &w, &x, &u) > 0 {
-			return v, w, x, nil
+	st.ensureIndex()
+
+	var v, w, x Vec2
+	found := st.strtree.queryPoint(u, func(t Triangle) bool {
+		if st.tri[t] && liftedContains(t[0], t[1], t[2], u) {
+			v, w, x = t[0], t[1], t[2]
+			return true
+		}
+		return false
+	})
+	if found {
+		return v, w, x, nil
+	}
+
+	for _, t := range st.pending {
+		if st.tri[t] && liftedContains(t[0], t[1], t[2], u) {
+			return t[0], t[1], t[2], nil
+		}
+	}
+
+	for t := range st.tri {
+		if liftedContains(t[0], t[1], t[2], u) {
+			return t[0], t[1], t[2], nil
 		}
 	}
 	return Vec2{}, Vec2{}, Vec2{}, fmt.Errorf("no triangle's open circumdisk contains %+v", u)
@@ -298,12 +347,10 @@ func (st *Store2D) dig(u Vec2, v, w Vec2) error {
 	if !ok {
 		return nil // triangle already deleted
 	}
-	u3 := Vec3{u[0], u[1], u.Dot(&u) - weight}
-	v3 := Vec3{v[0], v[1], v.Dot(&v) - weight}
-	w3 := Vec3{w[0], w[1], w.Dot(&w) - weight}
-	x3 := Vec3{x[0], x[1], x.Dot(&x) - weight}
-	if Orient3D(u3, v3, w3, x3) > 0 {
-		// if InCircle(&u, &v, &w, &x) > 0 {
+	if st.constrained(v, w) {
+		return st.AddTriangle(u, v, w) // v-w is a constrained edge: never flip it
+	}
+	if InCircleRobust(&u, &v, &w, &x) > 0 {
 		if err := st.DeleteTriangle(w, v, x); err != nil {
 			return err
 		}
@@ -335,7 +382,6 @@ func (st *Store2D) InsertVertex(u Vec2, v, w, x Vec2) error {
 		return err
 	}
 	st.InsertGhost()
-	// st.FixAngles()
 	return nil
 }
 
@@ -354,90 +400,6 @@ func anglerads(u, v, w Vec2) float32 {
 	return (2 * area) / (a * b)
 }
 
-func circumcenter(a, b, c Vec2) Vec2 {
-
-	var x Vec2
-	x.Add(&a, &b)
-	x.DivScalar(2)
-	x.Add(&x, &c)
-	x.DivScalar(2)
-	log.Println(x)
-	return x
-
-	//
-	// d := 2 * (a[0]*(b[1]-c[1]) + b[0]*(c[1]-a[1]) + c[0]*(a[1]-b[1]))
-	// x := (a.Dot(&a)*b[1] - c[1] + b.Dot(&b)*(c[1]-a[1]) + c.Dot(&c)*(a[1]-b[1])) / d
-	// y := (a.Dot(&a)*c[0] - b[0] + b.Dot(&b)*(a[0]-c[0]) + c.Dot(&c)*(b[0]-a[0])) / d
-	// log.Println(x, y)
-	// return Vec2{x, y}
-
-	//
-	// a := Mat3{
-	// {u[0], u[1], 1},
-	// {v[0], v[1], 1},
-	// {w[0], w[1], 1},
-	// }.Det()
-	// b := Mat3{
-	// {u[0], u[1], u.Dot(&u)},
-	// {v[0], v[1], v.Dot(&v)},
-	// {w[0], w[1], w.Dot(&w)},
-	// }.Det()
-	// log.Println("circumcenter", a, b)
-	// return Vec2{a, b}
-}
-
-func (st *Store2D) hasboundary(u, v, w Vec2) bool {
-	_, a := st.Adjacent(v, u)
-	_, b := st.Adjacent(w, v)
-	_, c := st.Adjacent(u, w)
-	return !a || !b || !c
-}
-
-func (st *Store2D) FixAngles() {
-	const y = 0.39
-	// var err error
-LOOP:
-	for e0, e1 := range st.m {
-		u, v, w := e0[0], e1[0], e1[1]
-		if u == GhostVertex || v == GhostVertex || w == GhostVertex {
-			continue
-		}
-		if !st.hasboundary(u, v, w) && anglerads(u, v, w) < y {
-			// x := circumcenter(u, v, w)
-			// u, v, w, err = st.PointLocation(x)
-			// if err == nil {
-			// st.InsertVertex(x, u, v, w)
-			// goto LOOP
-			// }
-			st.InsertVertex(circumcenter(u, v, w), u, v, w)
-			goto LOOP
-			// break
-		}
-		if !st.hasboundary(v, w, u) && anglerads(v, w, u) < y {
-			// x := circumcenter(v, w, u)
-			// u, v, w, err = st.PointLocation(x)
-			// if err == nil {
-			// st.InsertVertex(x, u, v, w)
-			// goto LOOP
-			// }
-			st.InsertVertex(circumcenter(v, w, u), v, w, u)
-			goto LOOP
-			// break
-		}
-		if !st.hasboundary(w, u, v) && anglerads(w, u, v) < y {
-			// x := circumcenter(w, u, v)
-			// u, v, w, err = st.PointLocation(x)
-			// if err == nil {
-			// st.InsertVertex(x, u, v, w)
-			// goto LOOP
-			// }
-			st.InsertVertex(circumcenter(w, u, v), w, u, v)
-			goto LOOP
-			// break
-		}
-	}
-}
-
 // func (st *Store2D) InsertVertexAtConflict(u Vec2, v, w, x Vec2) {
 // }
 
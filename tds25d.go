@@ -0,0 +1,300 @@
+package tds
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// This file adds a 2.5D TIN (triangulated irregular network) layer on top of
+// Store2D: Store25D attaches an elevation to each vertex so the underlying
+// triangulation can answer terrain-style queries (cross sections, grid
+// rasterization, contour extraction). It reuses Store2D's triangulation and
+// STR tree unchanged; only the per-vertex Z values and the derived queries
+// live here. Encoding Store25D's results to WKB/GeoJSON is a sibling concern
+// handled by the tds/wkb and tds/geojson subpackages.
+
+// TriangleZ is a triangle in 3D, each Vec3 holding a vertex's (x, y, z).
+type TriangleZ [3]Vec3
+
+// LineStringZ is a connected 3D polyline, e.g. one chain of a cross section
+// or contour line.
+type LineStringZ []Vec3
+
+// MultiPointZ is an unordered set of 3D points, e.g. a TIN's vertices.
+type MultiPointZ []Vec3
+
+// MultiLineStringZ is an unordered set of 3D polylines, e.g. the chains
+// Isolines returns for one elevation.
+type MultiLineStringZ []LineStringZ
+
+// Store25D is a Store2D with an elevation attached to each vertex, the TIN
+// model used by hydrographic and terrain mesh formats.
+type Store25D struct {
+	*Store2D
+	z map[Vec2]float32
+}
+
+// NewStore25D returns an empty Store25D.
+func NewStore25D() *Store25D {
+	return &Store25D{Store2D: NewStore2D(), z: make(map[Vec2]float32)}
+}
+
+// SetZ sets u's elevation. u need not already be a triangulation vertex;
+// callers typically SetZ every input point before triangulating it.
+func (st *Store25D) SetZ(u Vec2, z float32) { st.z[u] = z }
+
+// Z returns u's elevation, or 0 if u has none set.
+func (st *Store25D) Z(u Vec2) float32 { return st.z[u] }
+
+func (st *Store25D) vec3(u Vec2) Vec3 { return Vec3{u[0], u[1], st.z[u]} }
+
+// TriangleZ returns t lifted into 3D using each vertex's elevation.
+func (st *Store25D) TriangleZ(t Triangle) TriangleZ {
+	return TriangleZ{st.vec3(t[0]), st.vec3(t[1]), st.vec3(t[2])}
+}
+
+// VerticesZ returns every non-ghost vertex in the triangulation, lifted into
+// 3D using its elevation.
+func (st *Store25D) VerticesZ() MultiPointZ {
+	var out MultiPointZ
+	for _, u := range st.vertices() {
+		out = append(out, st.vec3(u))
+	}
+	return out
+}
+
+// barycentric returns the barycentric weights of p with respect to triangle
+// a, b, c; wa+wb+wc == 1, and all three are in [0, 1] iff p lies in the
+// closed triangle. Weights are computed from signedArea2D rather than
+// Orient2D's plain float32 determinant, since real terrain data routinely
+// produces thin slivers where the float32 path loses enough precision to
+// misplace an elevation sample near an edge.
+func barycentric(a, b, c, p Vec2) (wa, wb, wc float32) {
+	d := signedArea2D(a, b, c)
+	wa = float32(signedArea2D(b, c, p) / d)
+	wb = float32(signedArea2D(c, a, p) / d)
+	wc = 1 - wa - wb
+	return
+}
+
+// zAt interpolates t's elevation at p using barycentric weights; p is
+// assumed to lie within t.
+func (st *Store25D) zAt(t Triangle, p Vec2) float32 {
+	wa, wb, wc := barycentric(t[0], t[1], t[2], p)
+	return wa*st.z[t[0]] + wb*st.z[t[1]] + wc*st.z[t[2]]
+}
+
+// locateTriangle returns the non-ghost triangle containing p, querying the
+// STR tree for candidates whose bbox contains p before checking each with
+// the exact pointInTriangle test.
+func (st *Store2D) locateTriangle(p Vec2) (Triangle, bool) {
+	for _, t := range st.TrianglesIn(p, p) {
+		if t[0] == GhostVertex || t[1] == GhostVertex || t[2] == GhostVertex {
+			continue
+		}
+		if pointInTriangle(p, t[0], t[1], t[2]) {
+			return t, true
+		}
+	}
+	return Triangle{}, false
+}
+
+// clipToTriangle returns the portion, as a [tmin, tmax] subrange of [0, 1],
+// of the segment a+t*(b-a) that lies within the closed triangle t0, t1, t2.
+// ok is false if the segment misses the triangle entirely.
+func clipToTriangle(a, b, t0, t1, t2 Vec2) (tmin, tmax float32, ok bool) {
+	tmin, tmax = 0, 1
+	edges := [3][2]Vec2{{t0, t1}, {t1, t2}, {t2, t0}}
+	for _, e := range edges {
+		var edge, normal, da, db Vec2
+		edge.Sub(&e[1], &e[0])
+		normal = Vec2{-edge[1], edge[0]} // points into a CCW-wound triangle
+		da.Sub(&a, &e[0])
+		db.Sub(&b, &e[0])
+		d0, d1 := normal.Dot(&da), normal.Dot(&db)
+
+		if d0 >= 0 && d1 >= 0 {
+			continue // segment lies entirely within this half-plane
+		}
+		if d0 < 0 && d1 < 0 {
+			return 0, 0, false
+		}
+		tcross := d0 / (d0 - d1)
+		if d0 < 0 {
+			tmin = max32(tmin, tcross)
+		} else {
+			tmax = min32(tmax, tcross)
+		}
+		if tmin > tmax {
+			return 0, 0, false
+		}
+	}
+	return tmin, tmax, true
+}
+
+func min32(a, b float32) float32 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func max32(a, b float32) float32 {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func lerpVec2(a, b Vec2, t float32) Vec2 {
+	return Vec2{a[0] + t*(b[0]-a[0]), a[1] + t*(b[1]-a[1])}
+}
+
+// Vertical walks every non-ghost triangle the vertical plane through a and b
+// crosses, in order from a to b, calling fn with the 3D segment cut through
+// that triangle. This is the basic primitive for terrain or river cross
+// sections: concatenating the segments fn receives traces the ground
+// profile along a-b.
+func (st *Store25D) Vertical(a, b Vec2, fn func(seg LineStringZ)) error {
+	type hit struct {
+		tmin, tmax float32
+	}
+	hits := make(map[Triangle]hit)
+	min, max := a, a
+	for i := 0; i < 2; i++ {
+		min[i] = min32(a[i], b[i])
+		max[i] = max32(a[i], b[i])
+	}
+	for _, t := range st.TrianglesIn(min, max) {
+		if t[0] == GhostVertex || t[1] == GhostVertex || t[2] == GhostVertex {
+			continue
+		}
+		tmin, tmax, ok := clipToTriangle(a, b, t[0], t[1], t[2])
+		if !ok || tmin == tmax {
+			continue
+		}
+		hits[t] = hit{tmin, tmax}
+	}
+	if len(hits) == 0 {
+		return fmt.Errorf("tds: no triangle crosses %v-%v", a, b)
+	}
+
+	tris := make([]Triangle, 0, len(hits))
+	for t := range hits {
+		tris = append(tris, t)
+	}
+	sort.Slice(tris, func(i, j int) bool { return hits[tris[i]].tmin < hits[tris[j]].tmin })
+
+	for _, t := range tris {
+		h := hits[t]
+		p0, p1 := lerpVec2(a, b, h.tmin), lerpVec2(a, b, h.tmax)
+		seg := LineStringZ{
+			{p0[0], p0[1], st.zAt(t, p0)},
+			{p1[0], p1[1], st.zAt(t, p1)},
+		}
+		fn(seg)
+	}
+	return nil
+}
+
+// Rasterize samples the TIN's elevation on a regular grid covering [min,
+// max] at the given cellSize, interpolating within the triangle located (via
+// the STR tree) for each sample. Cells outside the triangulation are NaN.
+func (st *Store25D) Rasterize(min, max Vec2, cellSize float32) [][]float32 {
+	nx := int(math.Ceil(float64((max[0]-min[0])/cellSize))) + 1
+	ny := int(math.Ceil(float64((max[1]-min[1])/cellSize))) + 1
+
+	grid := make([][]float32, ny)
+	for j := 0; j < ny; j++ {
+		row := make([]float32, nx)
+		y := min[1] + float32(j)*cellSize
+		for i := 0; i < nx; i++ {
+			p := Vec2{min[0] + float32(i)*cellSize, y}
+			t, ok := st.locateTriangle(p)
+			if !ok {
+				row[i] = float32(math.NaN())
+				continue
+			}
+			row[i] = st.zAt(t, p)
+		}
+		grid[j] = row
+	}
+	return grid
+}
+
+func closeVec3(a, b Vec3, eps float32) bool {
+	return sqrt((a[0]-b[0])*(a[0]-b[0])+(a[1]-b[1])*(a[1]-b[1])+(a[2]-b[2])*(a[2]-b[2])) < eps
+}
+
+// Isolines extracts contour lines at each elevation in zs. For every
+// non-ghost triangle it linearly interpolates the crossing point along each
+// edge whose endpoints straddle the level, then stitches the resulting
+// per-triangle segments into connected chains by matching shared endpoints.
+func (st *Store25D) Isolines(zs []float32) []MultiLineStringZ {
+	out := make([]MultiLineStringZ, len(zs))
+	for i, z := range zs {
+		out[i] = st.isoline(z)
+	}
+	return out
+}
+
+func (st *Store25D) isoline(z float32) MultiLineStringZ {
+	const eps = 1e-5
+
+	var segs []LineStringZ
+	for t := range st.tri {
+		if t[0] == GhostVertex || t[1] == GhostVertex || t[2] == GhostVertex {
+			continue
+		}
+		var pts []Vec3
+		for i := 0; i < 3; i++ {
+			a, b := t[i], t[(i+1)%3]
+			za, zb := st.z[a], st.z[b]
+			if (za < z) == (zb < z) {
+				continue
+			}
+			w := (z - za) / (zb - za)
+			pts = append(pts, Vec3{a[0] + w*(b[0]-a[0]), a[1] + w*(b[1]-a[1]), z})
+		}
+		if len(pts) == 2 {
+			segs = append(segs, LineStringZ{pts[0], pts[1]})
+		}
+	}
+
+	var chains MultiLineStringZ
+	used := make([]bool, len(segs))
+	for i := range segs {
+		if used[i] {
+			continue
+		}
+		used[i] = true
+		chain := append(LineStringZ{}, segs[i]...)
+		extended := true
+		for extended {
+			extended = false
+			for j, s := range segs {
+				if used[j] {
+					continue
+				}
+				switch {
+				case closeVec3(chain[len(chain)-1], s[0], eps):
+					chain = append(chain, s[1])
+				case closeVec3(chain[len(chain)-1], s[1], eps):
+					chain = append(chain, s[0])
+				case closeVec3(chain[0], s[1], eps):
+					chain = append(LineStringZ{s[0]}, chain...)
+				case closeVec3(chain[0], s[0], eps):
+					chain = append(LineStringZ{s[1]}, chain...)
+				default:
+					continue
+				}
+				used[j] = true
+				extended = true
+				break
+			}
+		}
+		chains = append(chains, chain)
+	}
+	return chains
+}
@@ -0,0 +1,98 @@
+package tds
+
+import "testing"
+
+// gridStore25D builds the same 2x2 grid as gridStore2D, but with each vertex
+// given an elevation that rises toward the center.
+func gridStore25D(t *testing.T) *Store25D {
+	t.Helper()
+	st := NewStore25D()
+	v := func(x, y, z float32) Vec2 {
+		p := Vec2{x, y}
+		st.SetZ(p, z)
+		return p
+	}
+	p00, p10, p20 := v(0, 0, 0), v(1, 0, 1), v(2, 0, 0)
+	p01, p11, p21 := v(0, 1, 1), v(1, 1, 2), v(2, 1, 1)
+	p02, p12, p22 := v(0, 2, 0), v(1, 2, 1), v(2, 2, 0)
+
+	tris := [][3]Vec2{
+		{p00, p10, p11}, {p00, p11, p01},
+		{p10, p20, p21}, {p10, p21, p11},
+		{p01, p11, p12}, {p01, p12, p02},
+		{p11, p21, p22}, {p11, p22, p12},
+	}
+	for _, tr := range tris {
+		if err := st.AddTriangle(tr[0], tr[1], tr[2]); err != nil {
+			t.Fatalf("AddTriangle(%v): %v", tr, err)
+		}
+	}
+	return st
+}
+
+// TestBarycentricThinTriangle exercises a thin, near-degenerate triangle
+// like real terrain data produces: p sits exactly on the a-c edge, so wb
+// must come out exactly 0 even though the triangle's signed area is tiny
+// enough that a plain float32 determinant can round to the wrong value.
+func TestBarycentricThinTriangle(t *testing.T) {
+	a := Vec2{0, 0}
+	b := Vec2{100000, 0.0001}
+	c := Vec2{200000, 0}
+	p := Vec2{100000, 0}
+
+	wa, wb, wc := barycentric(a, b, c, p)
+	if wb != 0 {
+		t.Errorf("want wb == 0 for p on edge a-c, have %v", wb)
+	}
+	if sum := wa + wb + wc; sum < 0.999 || sum > 1.001 {
+		t.Errorf("want wa+wb+wc == 1, have %v", sum)
+	}
+}
+
+func TestStore25DRasterize(t *testing.T) {
+	st := gridStore25D(t)
+	grid := st.Rasterize(Vec2{0, 0}, Vec2{2, 2}, 1)
+	if len(grid) != 3 || len(grid[0]) != 3 {
+		t.Fatalf("want a 3x3 grid, have %vx%v", len(grid), len(grid[0]))
+	}
+	if grid[1][1] != 2 {
+		t.Errorf("want the center sample to match its vertex elevation 2, have %v", grid[1][1])
+	}
+	if grid[0][0] != 0 {
+		t.Errorf("want the origin sample to match its vertex elevation 0, have %v", grid[0][0])
+	}
+}
+
+func TestStore25DVertical(t *testing.T) {
+	st := gridStore25D(t)
+	var segs []LineStringZ
+	err := st.Vertical(Vec2{0.2, 0.3}, Vec2{1.8, 1.6}, func(seg LineStringZ) {
+		segs = append(segs, seg)
+	})
+	if err != nil {
+		t.Fatalf("Vertical: %v", err)
+	}
+	if len(segs) == 0 {
+		t.Fatal("want at least one crossed triangle")
+	}
+	// The walked chain should be continuous: each segment's end should meet
+	// the next segment's start.
+	for i := 1; i < len(segs); i++ {
+		if !closeVec3(segs[i-1][len(segs[i-1])-1], segs[i][0], 1e-3) {
+			t.Errorf("segment %v doesn't connect to segment %v: %v vs %v", i-1, i, segs[i-1], segs[i])
+		}
+	}
+}
+
+func TestStore25DIsolines(t *testing.T) {
+	st := gridStore25D(t)
+	lines := st.Isolines([]float32{0.5, 1.5})
+	if len(lines) != 2 {
+		t.Fatalf("want one result per requested elevation, have %v", len(lines))
+	}
+	for i, mls := range lines {
+		if len(mls) == 0 {
+			t.Errorf("want at least one contour chain at elevation index %v", i)
+		}
+	}
+}
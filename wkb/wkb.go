@@ -0,0 +1,397 @@
+// Package wkb reads and writes tds triangulations as OGC Well-Known Binary
+// geometries: a MultiPoint for a triangulation's vertices, a MultiLineString
+// for its edges, and a TIN (WKB type 16) for the triangulation itself. Plain
+// Store2D values are written in the XY variant of MultiPoint and
+// MultiLineString, and by Encode as a TIN Z with every Z == 0; Store25D
+// values, which carry a real elevation per vertex, are written in the XYZ
+// (Z-tagged type code) variant via the Z-suffixed functions.
+package wkb
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/dskinner/tds"
+)
+
+// WKB geometry type codes (OGC Simple Features Access). The XYZ variant of
+// each type is its code plus 1000.
+const (
+	wkbPoint             = 1
+	wkbLineString        = 2
+	wkbPolygon           = 3
+	wkbMultiPoint        = 4
+	wkbMultiLineString   = 5
+	wkbPolyhedralSurface = 15
+	wkbTIN               = 16
+	wkbZOffset           = 1000
+)
+
+func writeOrder(w io.Writer, order binary.ByteOrder) error {
+	marker := byte(0)
+	if order == binary.LittleEndian {
+		marker = 1
+	}
+	_, err := w.Write([]byte{marker})
+	return err
+}
+
+func readOrder(r io.Reader) (binary.ByteOrder, error) {
+	var b [1]byte
+	if _, err := io.ReadFull(r, b[:]); err != nil {
+		return nil, err
+	}
+	if b[0] == 1 {
+		return binary.LittleEndian, nil
+	}
+	return binary.BigEndian, nil
+}
+
+func writeHeader(w io.Writer, order binary.ByteOrder, typ uint32) error {
+	if err := writeOrder(w, order); err != nil {
+		return err
+	}
+	return binary.Write(w, order, typ)
+}
+
+func readHeader(r io.Reader) (order binary.ByteOrder, base uint32, withZ bool, err error) {
+	order, err = readOrder(r)
+	if err != nil {
+		return nil, 0, false, err
+	}
+	var typ uint32
+	if err := binary.Read(r, order, &typ); err != nil {
+		return nil, 0, false, err
+	}
+	if typ >= wkbZOffset {
+		return order, typ - wkbZOffset, true, nil
+	}
+	return order, typ, false, nil
+}
+
+func writeCoords(w io.Writer, order binary.ByteOrder, p tds.Vec3, withZ bool) error {
+	if err := binary.Write(w, order, float64(p[0])); err != nil {
+		return err
+	}
+	if err := binary.Write(w, order, float64(p[1])); err != nil {
+		return err
+	}
+	if !withZ {
+		return nil
+	}
+	return binary.Write(w, order, float64(p[2]))
+}
+
+func writePoint(w io.Writer, order binary.ByteOrder, p tds.Vec3, withZ bool) error {
+	typ := uint32(wkbPoint)
+	if withZ {
+		typ += wkbZOffset
+	}
+	if err := writeHeader(w, order, typ); err != nil {
+		return err
+	}
+	return writeCoords(w, order, p, withZ)
+}
+
+func writeLineString(w io.Writer, order binary.ByteOrder, pts []tds.Vec3, withZ bool) error {
+	typ := uint32(wkbLineString)
+	if withZ {
+		typ += wkbZOffset
+	}
+	if err := writeHeader(w, order, typ); err != nil {
+		return err
+	}
+	if err := binary.Write(w, order, uint32(len(pts))); err != nil {
+		return err
+	}
+	for _, p := range pts {
+		if err := writeCoords(w, order, p, withZ); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeTrianglePolygon writes a triangle as a WKB Polygon with a single ring
+// closed back to its first vertex, the patch representation a TIN's body is
+// built from.
+func writeTrianglePolygon(w io.Writer, order binary.ByteOrder, t tds.TriangleZ, withZ bool) error {
+	typ := uint32(wkbPolygon)
+	if withZ {
+		typ += wkbZOffset
+	}
+	if err := writeHeader(w, order, typ); err != nil {
+		return err
+	}
+	if err := binary.Write(w, order, uint32(1)); err != nil { // one ring
+		return err
+	}
+	if err := binary.Write(w, order, uint32(4)); err != nil { // 3 vertices, closed
+		return err
+	}
+	for _, p := range []tds.Vec3{t[0], t[1], t[2], t[0]} {
+		if err := writeCoords(w, order, p, withZ); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func nonGhostTriangles(st *tds.Store2D) []tds.Triangle {
+	var out []tds.Triangle
+	for _, t := range st.Triangles() {
+		if t[0] == tds.GhostVertex || t[1] == tds.GhostVertex || t[2] == tds.GhostVertex {
+			continue
+		}
+		out = append(out, t)
+	}
+	return out
+}
+
+func uniqueEdges(st *tds.Store2D) []tds.Mat2 {
+	seen := make(map[tds.Mat2]bool)
+	var out []tds.Mat2
+	for e := range st.M() {
+		if e[0] == tds.GhostVertex || e[1] == tds.GhostVertex {
+			continue
+		}
+		if seen[tds.Mat2{e[1], e[0]}] {
+			continue
+		}
+		seen[e] = true
+		out = append(out, e)
+	}
+	return out
+}
+
+// EncodeMultiPoint writes st's vertices as a WKB MultiPoint.
+func EncodeMultiPoint(w io.Writer, st *tds.Store2D, order binary.ByteOrder) error {
+	verts := st.VertexSet()
+	if err := writeHeader(w, order, wkbMultiPoint); err != nil {
+		return err
+	}
+	if err := binary.Write(w, order, uint32(len(verts))); err != nil {
+		return err
+	}
+	for _, v := range verts {
+		if err := writePoint(w, order, tds.Vec3{v[0], v[1], 0}, false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// EncodeMultiPointZ writes st's vertices, with elevation, as a WKB
+// MultiPoint Z.
+func EncodeMultiPointZ(w io.Writer, st *tds.Store25D, order binary.ByteOrder) error {
+	pts := st.VerticesZ()
+	if err := writeHeader(w, order, wkbMultiPoint+wkbZOffset); err != nil {
+		return err
+	}
+	if err := binary.Write(w, order, uint32(len(pts))); err != nil {
+		return err
+	}
+	for _, p := range pts {
+		if err := writePoint(w, order, p, true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// EncodeMultiLineString writes st's unique edges as a WKB MultiLineString.
+func EncodeMultiLineString(w io.Writer, st *tds.Store2D, order binary.ByteOrder) error {
+	edges := uniqueEdges(st)
+	if err := writeHeader(w, order, wkbMultiLineString); err != nil {
+		return err
+	}
+	if err := binary.Write(w, order, uint32(len(edges))); err != nil {
+		return err
+	}
+	for _, e := range edges {
+		pts := []tds.Vec3{{e[0][0], e[0][1], 0}, {e[1][0], e[1][1], 0}}
+		if err := writeLineString(w, order, pts, false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// EncodeMultiLineStringZ writes st's unique edges, with elevation, as a WKB
+// MultiLineString Z.
+func EncodeMultiLineStringZ(w io.Writer, st *tds.Store25D, order binary.ByteOrder) error {
+	edges := uniqueEdges(st.Store2D)
+	if err := writeHeader(w, order, wkbMultiLineString+wkbZOffset); err != nil {
+		return err
+	}
+	if err := binary.Write(w, order, uint32(len(edges))); err != nil {
+		return err
+	}
+	for _, e := range edges {
+		a, b := e[0], e[1]
+		pts := []tds.Vec3{{a[0], a[1], st.Z(a)}, {b[0], b[1], st.Z(b)}}
+		if err := writeLineString(w, order, pts, true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Encode writes st's triangles as a WKB TIN Z, one Polygon patch per
+// triangle. Store2D carries no elevation, so every vertex is written with
+// Z == 0; see EncodeZ for a Store25D's real elevations.
+func Encode(w io.Writer, st *tds.Store2D, order binary.ByteOrder) error {
+	tris := nonGhostTriangles(st)
+	if err := writeHeader(w, order, wkbTIN+wkbZOffset); err != nil {
+		return err
+	}
+	if err := binary.Write(w, order, uint32(len(tris))); err != nil {
+		return err
+	}
+	for _, t := range tris {
+		tz := tds.TriangleZ{{t[0][0], t[0][1], 0}, {t[1][0], t[1][1], 0}, {t[2][0], t[2][1], 0}}
+		if err := writeTrianglePolygon(w, order, tz, true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// EncodeZ writes st's triangles, with each vertex's real elevation, as a WKB
+// TIN Z.
+func EncodeZ(w io.Writer, st *tds.Store25D, order binary.ByteOrder) error {
+	tris := nonGhostTriangles(st.Store2D)
+	if err := writeHeader(w, order, wkbTIN+wkbZOffset); err != nil {
+		return err
+	}
+	if err := binary.Write(w, order, uint32(len(tris))); err != nil {
+		return err
+	}
+	for _, t := range tris {
+		if err := writeTrianglePolygon(w, order, st.TriangleZ(t), true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Decode reads a WKB TIN or PolyhedralSurface (XY or XYZ) written by Encode,
+// discarding any Z, and reconstructs a Store2D from its triangle patches,
+// re-orienting each counter-clockwise as Store2D requires. Use DecodeZ to
+// keep elevation.
+func Decode(r io.Reader) (*tds.Store2D, error) {
+	st := tds.NewStore2D()
+	if err := decodeTIN(r, func(u, v, w tds.Vec2, _ [3]float32) error {
+		return st.AddTriangle(u, v, w)
+	}); err != nil {
+		return nil, err
+	}
+	st.InsertGhost()
+	return st, nil
+}
+
+// DecodeZ is Decode, but keeps each vertex's elevation in the returned
+// Store25D.
+func DecodeZ(r io.Reader) (*tds.Store25D, error) {
+	st := tds.NewStore25D()
+	if err := decodeTIN(r, func(u, v, w tds.Vec2, z [3]float32) error {
+		st.SetZ(u, z[0])
+		st.SetZ(v, z[1])
+		st.SetZ(w, z[2])
+		return st.AddTriangle(u, v, w)
+	}); err != nil {
+		return nil, err
+	}
+	st.InsertGhost()
+	return st, nil
+}
+
+// decodeTIN reads a WKB TIN/PolyhedralSurface's triangle patches and calls
+// addTriangle with each, re-oriented counter-clockwise, plus its vertices'
+// elevations (0 for an XY geometry).
+func decodeTIN(r io.Reader, addTriangle func(u, v, w tds.Vec2, z [3]float32) error) error {
+	order, base, _, err := readHeader(r)
+	if err != nil {
+		return err
+	}
+	if base != wkbTIN && base != wkbPolyhedralSurface {
+		return fmt.Errorf("wkb: Decode expects a TIN or PolyhedralSurface geometry, have type %d", base)
+	}
+
+	var n uint32
+	if err := binary.Read(r, order, &n); err != nil {
+		return err
+	}
+	for i := uint32(0); i < n; i++ {
+		u, v, w, z, err := readTrianglePolygon(r)
+		if err != nil {
+			return err
+		}
+		if tds.Orient2D(u, v, w) < 0 {
+			v, w = w, v
+			z[1], z[2] = z[2], z[1]
+		}
+		if err := addTriangle(u, v, w, z); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readTrianglePolygon reads one WKB Polygon patch: its own byte-order marker
+// and type, a single ring, and the 4 (closed) coordinates that ring must
+// have, returning the distinct first 3 vertices and their elevations.
+func readTrianglePolygon(r io.Reader) (u, v, w tds.Vec2, z [3]float32, err error) {
+	order, base, withZ, err := readHeader(r)
+	if err != nil {
+		return u, v, w, z, err
+	}
+	if base != wkbPolygon {
+		return u, v, w, z, fmt.Errorf("wkb: Decode expects a Polygon patch, have type %d", base)
+	}
+	var numRings uint32
+	if err := binary.Read(r, order, &numRings); err != nil {
+		return u, v, w, z, err
+	}
+	if numRings != 1 {
+		return u, v, w, z, fmt.Errorf("wkb: triangle patch must have exactly one ring, have %d", numRings)
+	}
+	var numPts uint32
+	if err := binary.Read(r, order, &numPts); err != nil {
+		return u, v, w, z, err
+	}
+	if numPts != 4 {
+		return u, v, w, z, fmt.Errorf("wkb: triangle patch ring must have 4 points (closed), have %d", numPts)
+	}
+
+	pts := make([]tds.Vec2, 4)
+	zs := make([]float32, 4)
+	for i := range pts {
+		p, zi, err := readCoordsZ(r, order, withZ)
+		if err != nil {
+			return u, v, w, z, err
+		}
+		pts[i], zs[i] = p, zi
+	}
+	if pts[0] != pts[3] {
+		return u, v, w, z, fmt.Errorf("wkb: triangle patch ring must close back to its first point")
+	}
+	return pts[0], pts[1], pts[2], [3]float32{zs[0], zs[1], zs[2]}, nil
+}
+
+func readCoordsZ(r io.Reader, order binary.ByteOrder, withZ bool) (tds.Vec2, float32, error) {
+	var x, y, z float64
+	if err := binary.Read(r, order, &x); err != nil {
+		return tds.Vec2{}, 0, err
+	}
+	if err := binary.Read(r, order, &y); err != nil {
+		return tds.Vec2{}, 0, err
+	}
+	if withZ {
+		if err := binary.Read(r, order, &z); err != nil {
+			return tds.Vec2{}, 0, err
+		}
+	}
+	return tds.Vec2{float32(x), float32(y)}, float32(z), nil
+}
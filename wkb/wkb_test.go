@@ -0,0 +1,106 @@
+package wkb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/dskinner/tds"
+)
+
+func TestEncodeDecode(t *testing.T) {
+	st := tds.NewStore2D()
+	v0, v1, v2 := tds.Vec2{0, 0}, tds.Vec2{1, 0}, tds.Vec2{1, 1}
+	if err := st.AddTriangle(v0, v1, v2); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := Encode(&buf, st, binary.LittleEndian); err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+
+	got, err := Decode(&buf)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if tris := nonGhostTriangles(got); len(tris) != 1 {
+		t.Fatalf("want 1 triangle, have %v", len(tris))
+	}
+}
+
+func TestEncodeDecodeZ(t *testing.T) {
+	st := tds.NewStore25D()
+	v0, v1, v2 := tds.Vec2{0, 0}, tds.Vec2{1, 0}, tds.Vec2{1, 1}
+	st.SetZ(v0, 1)
+	st.SetZ(v1, 2)
+	st.SetZ(v2, 3)
+	if err := st.AddTriangle(v0, v1, v2); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := EncodeZ(&buf, st, binary.BigEndian); err != nil {
+		t.Fatalf("EncodeZ: %v", err)
+	}
+
+	got, err := DecodeZ(&buf)
+	if err != nil {
+		t.Fatalf("DecodeZ: %v", err)
+	}
+	if got.Z(v0) != 1 || got.Z(v1) != 2 || got.Z(v2) != 3 {
+		t.Errorf("want elevations 1,2,3, have %v,%v,%v", got.Z(v0), got.Z(v1), got.Z(v2))
+	}
+}
+
+func TestDecodeReorientsClockwisePatches(t *testing.T) {
+	var buf bytes.Buffer
+	order := binary.LittleEndian
+	if err := writeHeader(&buf, order, wkbTIN+wkbZOffset); err != nil {
+		t.Fatal(err)
+	}
+	if err := binary.Write(&buf, order, uint32(1)); err != nil {
+		t.Fatal(err)
+	}
+	v0, v1, v2 := tds.Vec2{0, 0}, tds.Vec2{1, 0}, tds.Vec2{1, 1}
+	cw := tds.TriangleZ{{v0[0], v0[1], 0}, {v2[0], v2[1], 0}, {v1[0], v1[1], 0}} // clockwise
+	if err := writeTrianglePolygon(&buf, order, cw, true); err != nil {
+		t.Fatal(err)
+	}
+
+	st, err := Decode(&buf)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	tris := nonGhostTriangles(st)
+	if len(tris) != 1 {
+		t.Fatalf("want 1 triangle, have %v", len(tris))
+	}
+	if tds.Orient2D(tris[0][0], tris[0][1], tris[0][2]) <= 0 {
+		t.Errorf("want the decoded triangle re-oriented counter-clockwise, have %v", tris[0])
+	}
+}
+
+func TestEncodeMultiPointAndMultiLineString(t *testing.T) {
+	st := tds.NewStore2D()
+	v0, v1, v2 := tds.Vec2{0, 0}, tds.Vec2{1, 0}, tds.Vec2{1, 1}
+	if err := st.AddTriangle(v0, v1, v2); err != nil {
+		t.Fatal(err)
+	}
+
+	var mp bytes.Buffer
+	if err := EncodeMultiPoint(&mp, st, binary.LittleEndian); err != nil {
+		t.Fatalf("EncodeMultiPoint: %v", err)
+	}
+	if mp.Len() == 0 {
+		t.Error("want EncodeMultiPoint to write some bytes")
+	}
+
+	var mls bytes.Buffer
+	if err := EncodeMultiLineString(&mls, st, binary.LittleEndian); err != nil {
+		t.Fatalf("EncodeMultiLineString: %v", err)
+	}
+	if mls.Len() == 0 {
+		t.Error("want EncodeMultiLineString to write some bytes")
+	}
+}